@@ -0,0 +1,84 @@
+package exec
+
+import (
+	"strings"
+)
+
+const (
+	ansiEsc = '\x1b'
+	ansiBel = '\x07'
+	// ansiC1CSI denotes the UTF-8 encoding of the single character control sequence introducer U+009B
+	ansiC1CSI = "\xc2\x9b"
+)
+
+// StripANSI removes ANSI escape sequences like colors (SGR) and cursor movements (CSI) from str. Operating system commands like window titles and hyperlinks (OSC) and other escape sequences are removed as well. Unterminated sequences at the end of str are removed entirely, while a sequence interrupted by an invalid character ends right before that character.
+func StripANSI(str string) string {
+	if strings.IndexByte(str, ansiEsc) < 0 && !strings.Contains(str, ansiC1CSI) {
+		return str
+	}
+
+	var sb strings.Builder
+	sb.Grow(len(str))
+	for i := 0; i < len(str); {
+		switch {
+		case strings.HasPrefix(str[i:], ansiC1CSI):
+			i = skipCSI(str, i+len(ansiC1CSI))
+		case str[i] != ansiEsc:
+			sb.WriteByte(str[i])
+			i++
+		case i+1 >= len(str):
+			// lone escape at the end
+			i++
+		case str[i+1] == '[':
+			i = skipCSI(str, i+2)
+		case str[i+1] == ']':
+			i = skipOSC(str, i+2)
+		default:
+			i = skipEscape(str, i+1)
+		}
+	}
+	return sb.String()
+}
+
+// skipCSI returns the index after the control sequence whose parameters start at str[i].
+func skipCSI(str string, i int) int {
+	// parameter bytes followed by intermediate bytes
+	for i < len(str) && str[i] >= 0x20 && str[i] <= 0x3f {
+		i++
+	}
+	if i < len(str) && str[i] >= 0x40 && str[i] <= 0x7e {
+		// final byte
+		i++
+	}
+	return i
+}
+
+// skipOSC returns the index after the operating system command starting at str[i], which is terminated by BEL or ESC \.
+func skipOSC(str string, i int) int {
+	for i < len(str) {
+		if str[i] == ansiBel {
+			return i + 1
+		}
+		if str[i] == ansiEsc {
+			if i+1 < len(str) && str[i+1] == '\\' {
+				return i + 2
+			}
+			// a new escape sequence implicitly terminates the command
+			return i
+		}
+		i++
+	}
+	return i
+}
+
+// skipEscape returns the index after the escape sequence whose first byte after ESC is str[i].
+func skipEscape(str string, i int) int {
+	// intermediate bytes like ( of character set selections
+	for i < len(str) && str[i] >= 0x20 && str[i] <= 0x2f {
+		i++
+	}
+	if i < len(str) && str[i] >= 0x30 && str[i] <= 0x7e {
+		i++
+	}
+	return i
+}
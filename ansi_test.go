@@ -0,0 +1,53 @@
+package exec
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+/* ############################################# */
+/* ###               StripANSI               ### */
+/* ############################################# */
+
+func TestStripANSI(t *testing.T) {
+	assert.Equal(t, "plain text", StripANSI("plain text"))
+	assert.Equal(t, "error: failed", StripANSI("\x1b[1;31merror:\x1b[0m failed"))
+	assert.Equal(t, "256 true", StripANSI("\x1b[38;5;208m256\x1b[m \x1b[38;2;255;0;0mtrue\x1b[39m"))
+	assert.Equal(t, "50%\n100%", StripANSI("50%\x1b[2K\x1b[1G\n\x1b[?25l100%\x1b[?25h"))
+	assert.Equal(t, "äöü ✓", StripANSI("\x1b[32mäöü ✓\x1b[0m"))
+	assert.Equal(t, "bold", StripANSI("\u009b1mbold\u009b0m"))
+}
+
+func TestStripANSIOSC(t *testing.T) {
+	assert.Equal(t, "text", StripANSI("\x1b]0;window title\x07text"))
+	assert.Equal(t, "link", StripANSI("\x1b]8;;https://example.com\x1b\\link\x1b]8;;\x1b\\"))
+}
+
+func TestStripANSIOtherEscapes(t *testing.T) {
+	assert.Equal(t, "ab", StripANSI("a\x1b(Bb"))
+	assert.Equal(t, "ab", StripANSI("a\x1bMb"))
+	assert.Equal(t, "ab", StripANSI("a\x1b7\x1b8b"))
+}
+
+func TestStripANSIPartial(t *testing.T) {
+	assert.Equal(t, "text", StripANSI("text\x1b"))
+	assert.Equal(t, "text", StripANSI("text\x1b["))
+	assert.Equal(t, "text", StripANSI("text\x1b[1;3"))
+	assert.Equal(t, "text", StripANSI("text\x1b]0;unterminated"))
+	// invalid characters end a sequence and are kept
+	assert.Equal(t, "a\nb", StripANSI("a\x1b[1\nb"))
+	assert.Equal(t, "ab", StripANSI("a\x1b]0;title\x1b[31mb"))
+}
+
+func TestLocalExecutorStripANSI(t *testing.T) {
+	colored := "\x1b[1;31mred\x1b[0m plain\n"
+	out, code, err := NewLocalExecutor(WithStripANSI(true)).Run("printf", `\033[1;31mred\033[0m plain\n`)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "red plain\n", out)
+
+	out, _, err = NewLocalExecutor(WithStripANSI(false)).Run("printf", `\033[1;31mred\033[0m plain\n`)
+	assert.NoError(t, err)
+	assert.Equal(t, colored, out)
+}
@@ -0,0 +1,45 @@
+package exec
+
+import (
+	"os"
+	"runtime"
+	"strconv"
+)
+
+var (
+	// ArgMax denotes the maximum size in bytes of arguments and environment that is accepted when starting a process on the current platform. The value is a conservative default and can be adjusted to match the actual system limit.
+	ArgMax = defaultArgMax()
+)
+
+func defaultArgMax() int {
+	switch runtime.GOOS {
+	case "linux":
+		return 2097152
+	case "darwin":
+		return 1048576
+	case "windows":
+		// maximum length of the command line in characters
+		return 32767
+	default:
+		return 262144
+	}
+}
+
+// EstimateArgSize returns the approximate number of bytes required to pass the command, its arguments and the environment of the current process to a new process.
+func EstimateArgSize(command string, args ...string) int {
+	// every entry is null-terminated and referenced by a pointer
+	pointerSize := strconv.IntSize / 8
+	size := len(command) + 1 + pointerSize
+	for _, arg := range args {
+		size += len(arg) + 1 + pointerSize
+	}
+	for _, env := range os.Environ() {
+		size += len(env) + 1 + pointerSize
+	}
+	return size
+}
+
+// ExceedsArgMax returns true if the estimated size of command, arguments and environment exceeds ArgMax. Callers should pass data via stdin or argument files in this case.
+func ExceedsArgMax(command string, args ...string) bool {
+	return EstimateArgSize(command, args...) > ArgMax
+}
@@ -0,0 +1,43 @@
+package exec
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+/* ############################################# */
+/* ###             Argument Size             ### */
+/* ############################################# */
+
+func TestEstimateArgSize(t *testing.T) {
+	pointerSize := strconv.IntSize / 8
+	envSize := 0
+	for _, env := range os.Environ() {
+		envSize += len(env) + 1 + pointerSize
+	}
+
+	assert.Equal(t, envSize+4+pointerSize, EstimateArgSize("cmd"))
+	assert.Equal(t, envSize+4+4+2+3*pointerSize, EstimateArgSize("cmd", "foo", "b"))
+}
+
+func TestExceedsArgMax(t *testing.T) {
+	assert.False(t, ExceedsArgMax("cmd", "foo", "bar"))
+
+	args := make([]string, 0)
+	for i := 0; i < ArgMax/1000+1; i++ {
+		args = append(args, strings.Repeat("x", 1000))
+	}
+	assert.True(t, EstimateArgSize("cmd", args...) > ArgMax)
+	assert.True(t, ExceedsArgMax("cmd", args...))
+}
+
+func TestExceedsArgMaxCustom(t *testing.T) {
+	defer func(argMax int) { ArgMax = argMax }(ArgMax)
+	ArgMax = EstimateArgSize("cmd", "foo")
+	assert.False(t, ExceedsArgMax("cmd", "foo"))
+	assert.True(t, ExceedsArgMax("cmd", "foo", "b"))
+}
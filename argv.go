@@ -0,0 +1,31 @@
+package exec
+
+import (
+	"strings"
+
+	"github.com/sbreitf1/errors"
+)
+
+var (
+	// ErrInvalidArgv occurs when a command and its arguments cannot be passed to a new process.
+	ErrInvalidArgv = errors.New("Invalid argument vector")
+)
+
+// BuildArgv validates a command with its arguments and returns the argument vector with the command as first element, as expected by exec.Command and execve. ErrInvalidArgv is returned if the command is empty or the command or any argument contains a NUL byte, which cannot be represented in a C string and would otherwise fail or truncate the value when starting the process.
+func BuildArgv(command string, args ...string) ([]string, errors.Error) {
+	if len(command) == 0 {
+		return nil, ErrInvalidArgv.Make().Msg("Command must not be empty")
+	}
+	if strings.IndexByte(command, 0) >= 0 {
+		return nil, ErrInvalidArgv.Make().Msg("Command %q contains a NUL byte", command)
+	}
+	for i, arg := range args {
+		if strings.IndexByte(arg, 0) >= 0 {
+			return nil, ErrInvalidArgv.Make().Msg("Argument %d %q contains a NUL byte", i+1, arg)
+		}
+	}
+
+	argv := make([]string, 0, len(args)+1)
+	argv = append(argv, command)
+	return append(argv, args...), nil
+}
@@ -0,0 +1,43 @@
+package exec
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sbreitf1/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+/* ############################################# */
+/* ###               BuildArgv               ### */
+/* ############################################# */
+
+func TestBuildArgv(t *testing.T) {
+	argv, err := BuildArgv("ls", "-l", "", "foo bar")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"ls", "-l", "", "foo bar"}, argv)
+
+	argv, err = BuildArgv("ls")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"ls"}, argv)
+}
+
+func TestBuildArgvDoesNotAlias(t *testing.T) {
+	args := []string{"a", "b"}
+	argv, err := BuildArgv("cmd", args...)
+	assert.NoError(t, err)
+	argv[1] = "changed"
+	assert.Equal(t, []string{"a", "b"}, args)
+}
+
+func TestBuildArgvInvalid(t *testing.T) {
+	_, err := BuildArgv("")
+	assert.True(t, errors.InstanceOf(err, ErrInvalidArgv))
+
+	_, err = BuildArgv("ls\x00rm")
+	assert.True(t, errors.InstanceOf(err, ErrInvalidArgv))
+
+	_, err = BuildArgv("ls", "ok", "trunc\x00ated")
+	assert.True(t, errors.InstanceOf(err, ErrInvalidArgv))
+	assert.True(t, strings.Contains(err.Error(), "Argument 2"))
+}
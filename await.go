@@ -0,0 +1,160 @@
+package exec
+
+import (
+	"bytes"
+	"context"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sbreitf1/errors"
+)
+
+var (
+	// ErrNoMatch occurs when a command exited without producing the awaited output.
+	ErrNoMatch = errors.New("Command exited without matching output")
+)
+
+// RunAwait executes a command with given arguments using the DefaultExecutor and returns the first output line matching pattern as soon as it appears. The command keeps running in background after a match. The returned stop function must be called to stop the command and returns its result, see LocalExecutor.RunAwait. ErrTimeout is returned if no line matched within timeout and ErrNoMatch if the command exited without match.
+//
+// Commands of executors other than LocalExecutor cannot be stopped, so stop only waits for them to finish.
+func RunAwait(pattern *regexp.Regexp, timeout time.Duration, command string, args ...string) (string, func() RunResult, errors.Error) {
+	if e, ok := DefaultExecutor.(*LocalExecutor); ok {
+		return e.RunAwait(pattern, timeout, command, args...)
+	}
+
+	matcher := newLineMatcher(pattern)
+	c := &awaitedCommand{finished: make(chan struct{})}
+	go func() {
+		defer close(c.finished)
+		output, code, err := runStream(DefaultExecutor, matcher, command, args...)
+		matcher.Flush()
+		c.result = RunResult{Output: output, ExitCode: code, Err: err}
+	}()
+	line, err := awaitMatch(matcher, c, timeout)
+	return line, c.stop, err
+}
+
+// RunAwait executes a command and returns the first output line matching pattern as soon as it appears. This allows to wait until a daemon or server is ready. The command keeps running in background after a match until it exits or the returned stop function is called. Stop terminates the command like a cancelled context and returns the result of the execution, which denotes ErrCanceled if the command was still running. The command is stopped and ErrTimeout is returned if no line matched within timeout. ErrNoMatch is returned if the command exited without match, and the exit code is available from stop in this case.
+//
+// The stop function is never nil and can be called multiple times, so it is safe to defer it directly. Output written after the match is not collected anymore to avoid unlimited growth for long-running commands, so the result of stop only contains the output up to the match.
+//
+// The command is executed like Run, so it is subject to SetMaxConcurrent, WithTimeout and WithIdleTimeout, and is reported to OnRun and the MetricsCollector as soon as it has exited. Pattern is matched against the raw output lines, so output encoding and output filters are not applied to the returned line.
+func (e *LocalExecutor) RunAwait(pattern *regexp.Regexp, timeout time.Duration, command string, args ...string) (string, func() RunResult, errors.Error) {
+	matcher := newLineMatcher(pattern)
+	output := e.newOutputBuffer(matcher)
+	// the context must only stop the command on timeout or stop, but not after a match
+	ctx, cancel := context.WithCancel(context.Background())
+	c := &awaitedCommand{finished: make(chan struct{}), cancel: cancel}
+	go func() {
+		defer cancel()
+		defer close(c.finished)
+		c.result = e.executeWith(ctx, nil, output, output, output, command, args...)
+		matcher.Flush()
+	}()
+
+	line, err := awaitMatch(matcher, c, timeout)
+	if err == nil {
+		output.discard()
+	} else if errors.InstanceOf(err, ErrTimeout) {
+		c.stop()
+	}
+	return line, c.stop, err
+}
+
+// awaitedCommand denotes a command that is executed in background by RunAwait.
+type awaitedCommand struct {
+	// finished is closed as soon as result has been set
+	finished chan struct{}
+	result   RunResult
+	// cancel stops the command, nil if the command cannot be stopped
+	cancel func()
+}
+
+// stop stops the command if possible and returns its result as soon as it has finished.
+func (c *awaitedCommand) stop() RunResult {
+	if c.cancel != nil {
+		c.cancel()
+	}
+	<-c.finished
+	return c.result
+}
+
+func awaitMatch(matcher *lineMatcher, c *awaitedCommand, timeout time.Duration) (string, errors.Error) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case line := <-matcher.matches:
+		return line, nil
+
+	case <-c.finished:
+		// the last line might have matched right before exit
+		select {
+		case line := <-matcher.matches:
+			return line, nil
+		default:
+		}
+		if c.result.Err != nil {
+			return "", c.result.Err
+		}
+		return "", ErrNoMatch.Make()
+
+	case <-timer.C:
+		return "", ErrTimeout.Make()
+	}
+}
+
+// lineMatcher is a writer that reports the first line matching a pattern.
+type lineMatcher struct {
+	mutex   sync.Mutex
+	pattern *regexp.Regexp
+	buffer  bytes.Buffer
+	matched bool
+	matches chan string
+}
+
+func newLineMatcher(pattern *regexp.Regexp) *lineMatcher {
+	return &lineMatcher{pattern: pattern, matches: make(chan string, 1)}
+}
+
+func (m *lineMatcher) Write(p []byte) (int, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.matched {
+		return len(p), nil
+	}
+
+	m.buffer.Write(p)
+	for {
+		i := bytes.IndexByte(m.buffer.Bytes(), '\n')
+		if i < 0 {
+			return len(p), nil
+		}
+		line := string(m.buffer.Next(i + 1))
+		if m.check(line) {
+			return len(p), nil
+		}
+	}
+}
+
+// Flush checks the remaining incomplete line.
+func (m *lineMatcher) Flush() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if !m.matched && m.buffer.Len() > 0 {
+		m.check(m.buffer.String())
+	}
+}
+
+func (m *lineMatcher) check(line string) bool {
+	line = strings.TrimRight(line, "\r\n")
+	if m.pattern.MatchString(line) {
+		m.matched = true
+		m.buffer.Reset()
+		m.matches <- line
+		return true
+	}
+	return false
+}
@@ -0,0 +1,144 @@
+package exec
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/sbreitf1/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+/* ############################################# */
+/* ###               RunAwait                ### */
+/* ############################################# */
+
+func TestRunAwait(t *testing.T) {
+	start := time.Now()
+	line, stop, err := RunAwait(regexp.MustCompile(`ready on port \d+`), 5*time.Second, path("ready.sh"))
+	defer stop()
+	assert.NoError(t, err)
+	assert.Equal(t, "server ready on port 8080", line)
+	assert.True(t, time.Since(start) < 2*time.Second)
+}
+
+func TestRunAwaitStop(t *testing.T) {
+	start := time.Now()
+	line, stop, err := RunAwait(regexp.MustCompile(`ready`), 5*time.Second, path("ready.sh"))
+	assert.NoError(t, err)
+	assert.Equal(t, "server ready on port 8080", line)
+
+	// the command would keep running for another 2 seconds
+	result := stop()
+	assert.True(t, errors.InstanceOf(result.Err, ErrCanceled))
+	assert.Equal(t, "starting\nserver ready on port 8080\n", result.Output)
+	assert.True(t, time.Since(start) < 1500*time.Millisecond)
+	assert.Equal(t, result, stop())
+}
+
+func TestRunAwaitDiscardOutput(t *testing.T) {
+	_, stop, err := RunAwait(regexp.MustCompile(`ready`), 5*time.Second, "sh", "-c", "echo ready; sleep 0.2; echo later; sleep 5")
+	assert.NoError(t, err)
+	time.Sleep(400 * time.Millisecond)
+
+	result := stop()
+	assert.Equal(t, "ready\n", result.Output)
+	assert.True(t, result.Truncated)
+	assert.Equal(t, len("ready\nlater\n"), result.OutputBytes)
+}
+
+func TestRunAwaitNoMatch(t *testing.T) {
+	_, stop, err := RunAwait(regexp.MustCompile(`ready`), 5*time.Second, path("fail.sh"))
+	assert.True(t, errors.InstanceOf(err, ErrNoMatch))
+	assert.Equal(t, 1, stop().ExitCode)
+}
+
+func TestRunAwaitLastLine(t *testing.T) {
+	line, stop, err := RunAwait(regexp.MustCompile(`output`), 5*time.Second, path("fail.sh"))
+	assert.NoError(t, err)
+	assert.Equal(t, "error output", line)
+	stop()
+}
+
+func TestRunAwaitTimeout(t *testing.T) {
+	_, stop, err := RunAwait(regexp.MustCompile(`never`), 200*time.Millisecond, path("signal.sh"))
+	assert.True(t, errors.InstanceOf(err, ErrTimeout))
+	assert.True(t, errors.InstanceOf(stop().Err, ErrCanceled))
+}
+
+func TestRunAwaitError(t *testing.T) {
+	_, stop, err := RunAwait(regexp.MustCompile(`never`), time.Second, path("noexec.txt"))
+	assert.True(t, errors.InstanceOf(err, ErrPermissionDenied))
+	assert.True(t, errors.InstanceOf(stop().Err, ErrPermissionDenied))
+}
+
+func TestRunAwaitOnRun(t *testing.T) {
+	defer restoreOnRun(OnRun)
+	results := recordRuns()
+
+	// the background command is reported as soon as it has been stopped by the executor timeout
+	start := time.Now()
+	e := NewLocalExecutor(WithTimeout(500 * time.Millisecond))
+	line, stop, err := e.RunAwait(regexp.MustCompile(`ready`), 5*time.Second, path("ready.sh"))
+	assert.NoError(t, err)
+	assert.Equal(t, "server ready on port 8080", line)
+	result := <-results
+	assert.True(t, errors.InstanceOf(result.Err, ErrTimeout))
+	assert.Equal(t, "starting\nserver ready on port 8080\n", result.Output)
+	assert.True(t, time.Since(start) < 1500*time.Millisecond)
+	assert.Equal(t, result, stop())
+
+	// commands stopped by RunAwait are reported before it returns
+	_, _, err = e.RunAwait(regexp.MustCompile(`never`), 100*time.Millisecond, path("signal.sh"))
+	assert.True(t, errors.InstanceOf(err, ErrTimeout))
+	select {
+	case result := <-results:
+		assert.True(t, errors.InstanceOf(result.Err, ErrCanceled))
+	default:
+		assert.Fail(t, "OnRun has not been called")
+	}
+}
+
+func TestRunAwaitMaxConcurrent(t *testing.T) {
+	SetMaxConcurrent(1)
+	defer SetMaxConcurrent(0)
+
+	// the slot is held until the background command has been stopped
+	e := NewLocalExecutor()
+	_, stop, err := e.RunAwait(regexp.MustCompile(`ready`), 5*time.Second, path("ready.sh"))
+	assert.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_, _, err = e.RunContext(ctx, path("success.sh"))
+	assert.True(t, errors.InstanceOf(err, ErrTimeout))
+
+	stop()
+	_, _, err = e.Run(path("success.sh"))
+	assert.NoError(t, err)
+}
+
+func TestRunAwaitMock(t *testing.T) {
+	defer restoreDefaultExecutor(DefaultExecutor)
+	DefaultExecutor = NewMockExecutor(func(command string, args ...string) (string, int, errors.Error) {
+		return "foo\nbar 42\nbaz", 3, nil
+	})
+
+	line, stop, err := RunAwait(regexp.MustCompile(`bar`), time.Second, "newcommand")
+	assert.NoError(t, err)
+	assert.Equal(t, "bar 42", line)
+	assert.Equal(t, 3, stop().ExitCode)
+
+	line, _, err = RunAwait(regexp.MustCompile(`baz`), time.Second, "newcommand")
+	assert.NoError(t, err)
+	assert.Equal(t, "baz", line)
+}
+
+// recordRuns replaces OnRun to collect the results of all executed commands.
+func recordRuns() chan RunResult {
+	results := make(chan RunResult, 16)
+	OnRun = func(command string, args []string, result RunResult) {
+		results <- result
+	}
+	return results
+}
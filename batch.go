@@ -0,0 +1,107 @@
+package exec
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/sbreitf1/errors"
+)
+
+// BatchOption denotes a configuration option for RunBatch.
+type BatchOption func(c *batchConfig)
+
+type batchConfig struct {
+	grouped bool
+}
+
+// WithGroupedOutput buffers the output of every job and writes it as a single contiguous block when the job has finished. Output of concurrent jobs is not interleaved in this mode.
+func WithGroupedOutput() BatchOption {
+	return func(c *batchConfig) {
+		c.grouped = true
+	}
+}
+
+// RunAll executes all commands using the DefaultExecutor with at most concurrency commands running at the same time. The results are returned in the order of the given commands. A concurrency of zero or less runs all commands at once. Details like OutputBytes, Truncated and Signal are only set if the DefaultExecutor is a LocalExecutor.
+func RunAll(concurrency int, commands []CommandSpec) []RunResult {
+	return runAll(DefaultExecutor, concurrency, commands, nil, batchConfig{})
+}
+
+// RunBatch executes all commands like RunAll and additionally writes their output to w while they are running. Output of concurrent jobs may be interleaved unless WithGroupedOutput is used.
+func RunBatch(w io.Writer, concurrency int, commands []CommandSpec, options ...BatchOption) []RunResult {
+	var config batchConfig
+	for _, option := range options {
+		option(&config)
+	}
+
+	return runAll(DefaultExecutor, concurrency, commands, &lockedWriter{w: w}, config)
+}
+
+func runAll(e Executor, concurrency int, commands []CommandSpec, w io.Writer, config batchConfig) []RunResult {
+	if concurrency <= 0 || concurrency > len(commands) {
+		concurrency = len(commands)
+	}
+
+	results := make([]RunResult, len(commands))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for worker := 0; worker < concurrency; worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = runJob(e, commands[i], w, config)
+			}
+		}()
+	}
+
+	for i := range commands {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return results
+}
+
+// runJob executes a single command of a batch and forwards the output to w if not nil. OutputBytes and the other execution details are only reported by LocalExecutors and are left zero for all other executors.
+func runJob(e Executor, spec CommandSpec, w io.Writer, config batchConfig) RunResult {
+	if local, ok := e.(*LocalExecutor); ok {
+		var stream io.Writer
+		if w != nil && !config.grouped {
+			stream = w
+		}
+		result := local.execute(context.Background(), stream, spec.Command, spec.Args...)
+		if w != nil && config.grouped {
+			io.WriteString(w, result.Output)
+		}
+		return result
+	}
+
+	start := time.Now()
+	var output string
+	var code int
+	var err errors.Error
+	switch {
+	case w == nil:
+		output, code, err = e.Run(spec.Command, spec.Args...)
+	case config.grouped:
+		output, code, err = e.Run(spec.Command, spec.Args...)
+		io.WriteString(w, output)
+	default:
+		output, code, err = runStream(e, w, spec.Command, spec.Args...)
+	}
+	return RunResult{Output: output, ExitCode: code, Err: err, Duration: time.Since(start)}
+}
+
+// lockedWriter serializes writes of concurrent jobs.
+type lockedWriter struct {
+	mutex sync.Mutex
+	w     io.Writer
+}
+
+func (w *lockedWriter) Write(p []byte) (int, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.w.Write(p)
+}
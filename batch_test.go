@@ -0,0 +1,121 @@
+package exec
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sbreitf1/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+/* ############################################# */
+/* ###                RunAll                 ### */
+/* ############################################# */
+
+func TestRunAll(t *testing.T) {
+	results := RunAll(2, []CommandSpec{
+		{path("success.sh"), nil},
+		{path("fail.sh"), nil},
+		{path("args.sh"), []string{"foo", "bar"}},
+		{path("noexec.txt"), nil},
+	})
+	assert.Len(t, results, 4)
+
+	assert.NoError(t, results[0].Err)
+	assert.Equal(t, 0, results[0].ExitCode)
+	assert.True(t, strings.Contains(results[0].Output, "some test output here"))
+
+	assert.NoError(t, results[1].Err)
+	assert.Equal(t, 1, results[1].ExitCode)
+	assert.True(t, strings.Contains(results[1].Output, "error output"))
+
+	assert.NoError(t, results[2].Err)
+	assert.True(t, strings.Contains(results[2].Output, "1foo ; 2bar"))
+
+	assert.True(t, errors.InstanceOf(results[3].Err, ErrPermissionDenied))
+}
+
+func TestRunAllOutputBytes(t *testing.T) {
+	defer restoreDefaultExecutor(DefaultExecutor)
+	DefaultExecutor = NewLocalExecutor(WithMaxOutput(4))
+	results := RunAll(1, []CommandSpec{{path("success.sh"), nil}})
+	assert.Equal(t, "some", results[0].Output)
+	assert.True(t, results[0].Truncated)
+	assert.True(t, results[0].OutputBytes > 4)
+
+	// other executors cannot report the produced bytes
+	DefaultExecutor = NewMockExecutor(func(command string, args ...string) (string, int, errors.Error) {
+		return "foobar", 0, nil
+	})
+	results = RunAll(1, []CommandSpec{{"newcommand", nil}})
+	assert.Equal(t, "foobar", results[0].Output)
+	assert.Equal(t, 0, results[0].OutputBytes)
+}
+
+func TestRunAllEmpty(t *testing.T) {
+	assert.Len(t, RunAll(4, nil), 0)
+}
+
+func TestRunAllConcurrencyLimit(t *testing.T) {
+	var mutex sync.Mutex
+	running, maxRunning := 0, 0
+	e := NewMockExecutor(func(command string, args ...string) (string, int, errors.Error) {
+		mutex.Lock()
+		running++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		mutex.Unlock()
+
+		time.Sleep(20 * time.Millisecond)
+
+		mutex.Lock()
+		running--
+		mutex.Unlock()
+		return command, 0, nil
+	})
+
+	commands := make([]CommandSpec, 10)
+	for i := range commands {
+		commands[i] = CommandSpec{Command: string(rune('a' + i))}
+	}
+	results := runAll(e, 3, commands, nil, batchConfig{})
+	assert.Equal(t, 3, maxRunning)
+	for i := range commands {
+		assert.Equal(t, commands[i].Command, results[i].Output)
+	}
+}
+
+/* ############################################# */
+/* ###               RunBatch                ### */
+/* ############################################# */
+
+func TestRunBatch(t *testing.T) {
+	var buf bytes.Buffer
+	results := RunBatch(&buf, 2, []CommandSpec{
+		{path("success.sh"), nil},
+		{path("fail.sh"), nil},
+	})
+	assert.Len(t, results, 2)
+	assert.Equal(t, 0, results[0].ExitCode)
+	assert.Equal(t, 1, results[1].ExitCode)
+	assert.True(t, strings.Contains(buf.String(), "some test output here"))
+	assert.True(t, strings.Contains(buf.String(), "error output"))
+}
+
+func TestRunBatchGroupedOutput(t *testing.T) {
+	var buf bytes.Buffer
+	results := RunBatch(&buf, 2, []CommandSpec{
+		{path("lines.sh"), []string{"a"}},
+		{path("lines.sh"), []string{"b"}},
+	}, WithGroupedOutput())
+	assert.Len(t, results, 2)
+
+	out := buf.String()
+	assert.Equal(t, len(results[0].Output)+len(results[1].Output), len(out))
+	assert.True(t, strings.Contains(out, results[0].Output))
+	assert.True(t, strings.Contains(out, results[1].Output))
+}
@@ -0,0 +1,132 @@
+package exec
+
+import (
+	"sync"
+	"time"
+
+	"github.com/sbreitf1/errors"
+)
+
+// CachingExecutor runs commands on an inner Executor and returns cached results for identical command lines that have been executed within a time-to-live. Only successful commands are cached by default, failed executions are never cached.
+type CachingExecutor struct {
+	inner        Executor
+	ttl          time.Duration
+	cacheNonZero bool
+	noCache      map[string]bool
+	mutex        sync.Mutex
+	entries      map[cacheKey]cacheEntry
+}
+
+// cacheKey separates the results of RunLine, Run and RunBytes, because Run of some executors returns processed output while RunBytes returns the raw output, and RunLine may interpret the command line differently than Parse does.
+type cacheKey struct {
+	method      string
+	commandLine string
+}
+
+type cacheEntry struct {
+	output  string
+	code    int
+	expires time.Time
+}
+
+// CacheOption denotes a configuration option for a CachingExecutor.
+type CacheOption func(e *CachingExecutor)
+
+// WithCacheNonZero caches results with non-zero exit codes as well.
+func WithCacheNonZero() CacheOption {
+	return func(e *CachingExecutor) {
+		e.cacheNonZero = true
+	}
+}
+
+// WithNoCache never caches the results of the given commands. Commands are matched by their name only, regardless of the arguments.
+func WithNoCache(commands ...string) CacheOption {
+	return func(e *CachingExecutor) {
+		for _, command := range commands {
+			e.noCache[command] = true
+		}
+	}
+}
+
+// RunLine returns the cached result of the command line or runs it on the inner executor. Results are cached separately from Run by the literal command line. The command name matched by WithNoCache is taken from the parsed command line, and command lines that cannot be parsed are never cached.
+func (e *CachingExecutor) RunLine(commandLine string) (string, int, errors.Error) {
+	key := cacheKey{"RunLine", commandLine}
+	if entry, ok := e.lookup(key); ok {
+		return entry.output, entry.code, nil
+	}
+
+	output, code, err := e.inner.RunLine(commandLine)
+	if command, _, parseErr := Parse(commandLine); parseErr == nil {
+		e.store(key, command, output, code, err)
+	}
+	return output, code, err
+}
+
+// Run returns the cached result of the command or executes it on the inner executor.
+func (e *CachingExecutor) Run(command string, args ...string) (string, int, errors.Error) {
+	key := cacheKey{"Run", GetCommandLine(command, args...)}
+	if entry, ok := e.lookup(key); ok {
+		return entry.output, entry.code, nil
+	}
+
+	output, code, err := e.inner.Run(command, args...)
+	e.store(key, command, output, code, err)
+	return output, code, err
+}
+
+// RunBytes returns the cached result of the command or executes it on the inner executor. Results are cached separately from Run, so the output bytes are never taken from the processed output of Run.
+func (e *CachingExecutor) RunBytes(command string, args ...string) ([]byte, int, errors.Error) {
+	key := cacheKey{"RunBytes", GetCommandLine(command, args...)}
+	if entry, ok := e.lookup(key); ok {
+		return []byte(entry.output), entry.code, nil
+	}
+
+	output, code, err := e.inner.RunBytes(command, args...)
+	e.store(key, command, string(output), code, err)
+	return output, code, err
+}
+
+// Clear removes all cached results.
+func (e *CachingExecutor) Clear() {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.entries = make(map[cacheKey]cacheEntry)
+}
+
+func (e *CachingExecutor) lookup(key cacheKey) (cacheEntry, bool) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	entry, ok := e.entries[key]
+	if !ok {
+		return cacheEntry{}, false
+	}
+	if time.Now().After(entry.expires) {
+		delete(e.entries, key)
+		return cacheEntry{}, false
+	}
+	return entry, true
+}
+
+func (e *CachingExecutor) store(key cacheKey, command string, output string, code int, err errors.Error) {
+	if err != nil || (code != 0 && !e.cacheNonZero) || e.noCache[command] {
+		return
+	}
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.entries[key] = cacheEntry{output, code, time.Now().Add(e.ttl)}
+}
+
+// NewCachingExecutor returns an executor that caches the results of commands run on inner for ttl.
+func NewCachingExecutor(inner Executor, ttl time.Duration, options ...CacheOption) *CachingExecutor {
+	e := &CachingExecutor{
+		inner:   inner,
+		ttl:     ttl,
+		noCache: make(map[string]bool),
+		entries: make(map[cacheKey]cacheEntry),
+	}
+	for _, option := range options {
+		option(e)
+	}
+	return e
+}
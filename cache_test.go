@@ -0,0 +1,116 @@
+package exec
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sbreitf1/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+/* ############################################# */
+/* ###            CachingExecutor            ### */
+/* ############################################# */
+
+func TestCachingExecutor(t *testing.T) {
+	inner, calls := countingMock(0)
+	e := NewCachingExecutor(inner, time.Hour)
+
+	out, code, err := e.Run("git", "config", "--get", "user.name")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "result 1", out)
+	// RunLine results are cached by the literal command line
+	out, _, _ = e.RunLine("git config --get user.name")
+	assert.Equal(t, "result 2", out)
+	out, _, _ = e.RunLine("git config --get user.name")
+	assert.Equal(t, "result 2", out)
+	// RunBytes results are cached separately from Run
+	bytes, _, _ := e.RunBytes("git", "config", "--get", "user.name")
+	assert.Equal(t, []byte("result 3"), bytes)
+	bytes, _, _ = e.RunBytes("git", "config", "--get", "user.name")
+	assert.Equal(t, []byte("result 3"), bytes)
+	out, _, _ = e.Run("git", "config", "--get", "user.email")
+	assert.Equal(t, "result 4", out)
+	assert.Equal(t, 4, *calls)
+
+	e.Clear()
+	out, _, _ = e.Run("git", "config", "--get", "user.name")
+	assert.Equal(t, "result 5", out)
+}
+
+func TestCachingExecutorRunAndRunBytes(t *testing.T) {
+	e := NewCachingExecutor(NewLocalExecutor(WithOutputFilter(strings.ToUpper)), time.Minute)
+
+	out, _, err := e.Run("echo", "hello")
+	assert.NoError(t, err)
+	assert.Equal(t, "HELLO\n", out)
+	raw, _, err := e.RunBytes("echo", "hello")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("hello\n"), raw)
+
+	raw, _, err = e.RunBytes("echo", "world")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("world\n"), raw)
+	out, _, err = e.Run("echo", "world")
+	assert.NoError(t, err)
+	assert.Equal(t, "WORLD\n", out)
+}
+
+func TestCachingExecutorExpired(t *testing.T) {
+	inner, calls := countingMock(0)
+	e := NewCachingExecutor(inner, 50*time.Millisecond)
+
+	e.Run("foo")
+	time.Sleep(100 * time.Millisecond)
+	out, _, _ := e.Run("foo")
+	assert.Equal(t, "result 2", out)
+	assert.Equal(t, 2, *calls)
+}
+
+func TestCachingExecutorNonZero(t *testing.T) {
+	inner, calls := countingMock(1)
+	e := NewCachingExecutor(inner, time.Hour)
+	e.Run("foo")
+	e.Run("foo")
+	assert.Equal(t, 2, *calls)
+
+	inner, calls = countingMock(1)
+	e = NewCachingExecutor(inner, time.Hour, WithCacheNonZero())
+	e.Run("foo")
+	_, code, _ := e.Run("foo")
+	assert.Equal(t, 1, code)
+	assert.Equal(t, 1, *calls)
+}
+
+func TestCachingExecutorNoCache(t *testing.T) {
+	inner, calls := countingMock(0)
+	e := NewCachingExecutor(inner, time.Hour, WithNoCache("date"))
+	e.Run("date")
+	e.Run("date")
+	e.Run("foo")
+	e.Run("foo")
+	assert.Equal(t, 3, *calls)
+}
+
+func TestCachingExecutorError(t *testing.T) {
+	calls := 0
+	e := NewCachingExecutor(NewMockExecutor(func(command string, args ...string) (string, int, errors.Error) {
+		calls++
+		return "", 0, ErrRun.Make()
+	}), time.Hour)
+	e.Run("foo")
+	_, _, err := e.Run("foo")
+	assert.True(t, errors.InstanceOf(err, ErrRun))
+	assert.Equal(t, 2, calls)
+}
+
+func countingMock(code int) (*MockExecutor, *int) {
+	calls := 0
+	return NewMockExecutor(func(command string, args ...string) (string, int, errors.Error) {
+		calls++
+		return "result " + strconv.Itoa(calls), code, nil
+	}), &calls
+}
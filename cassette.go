@@ -0,0 +1,198 @@
+package exec
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"sync"
+
+	"github.com/sbreitf1/errors"
+)
+
+var (
+	// ErrNoRecording occurs when a ReplayExecutor has no recorded interaction for a command line.
+	ErrNoRecording = errors.New("No recorded interaction for command line %s")
+	// ErrRecorded is returned by a ReplayExecutor for recorded errors that do not originate from this package.
+	ErrRecorded = errors.New("Recorded error: %s")
+	// ErrCassette occurs when a cassette file could not be read or written.
+	ErrCassette = errors.New("Unable to access cassette")
+)
+
+// Interaction denotes a single recorded command execution. Errors are recorded by their message and kind, see ErrorKind.
+type Interaction struct {
+	CommandLine string `json:"command_line"`
+	Output      string `json:"output"`
+	ExitCode    int    `json:"exit_code"`
+	Error       string `json:"error,omitempty"`
+	ErrorKind   string `json:"error_kind,omitempty"`
+}
+
+// newInteraction returns the interaction for a normalized command line that has been executed with the given result.
+func newInteraction(commandLine string, output string, code int, err errors.Error) Interaction {
+	interaction := Interaction{CommandLine: commandLine, Output: output, ExitCode: code}
+	if err != nil {
+		interaction.Error = err.Error()
+		interaction.ErrorKind = ErrorKind(err).String()
+	}
+	return interaction
+}
+
+// replayError returns an error of the recorded kind with the recorded message, or nil if no error has been recorded.
+func (i Interaction) replayError() errors.Error {
+	if len(i.Error) == 0 {
+		return nil
+	}
+
+	switch i.ErrorKind {
+	case KindParse.String():
+		return ErrParse.Make().Msg(i.Error)
+	case KindRun.String():
+		return ErrRun.Make().Msg(i.Error)
+	case KindExit.String():
+		if i.ExitCode == 0 {
+			// failures reported by the output like ErrFatalOutput cannot be restored as return code error
+			return ErrRecorded.Args(i.Error).Make()
+		}
+		return newReturnCodeError(i.ExitCode, i.Output)
+	case KindTimeout.String():
+		return ErrTimeout.Make().Msg(i.Error)
+	case KindCanceled.String():
+		return ErrCanceled.Make().Msg(i.Error)
+	default:
+		return ErrRecorded.Args(i.Error).Make()
+	}
+}
+
+// RecordingExecutor runs all commands on an inner Executor and records every command line together with its result. The recorded interactions can be saved as cassette and replayed using ReplayExecutor. It is safe for concurrent use.
+type RecordingExecutor struct {
+	inner        Executor
+	mutex        sync.Mutex
+	interactions []Interaction
+}
+
+// RunLine runs the command line on the inner executor and records the result under the normalized command line. Command lines that cannot be parsed are neither run nor recorded, because they could not be replayed.
+func (e *RecordingExecutor) RunLine(commandLine string) (string, int, errors.Error) {
+	canonical, err := CanonicalCommandLine(commandLine)
+	if err != nil {
+		return "", 0, err
+	}
+
+	output, code, err := e.inner.RunLine(commandLine)
+	e.record(newInteraction(canonical, output, code, err))
+	return output, code, err
+}
+
+// Run executes the command on the inner executor and records the result.
+func (e *RecordingExecutor) Run(command string, args ...string) (string, int, errors.Error) {
+	output, code, err := e.inner.Run(command, args...)
+	e.record(newInteraction(GetCommandLine(command, args...), output, code, err))
+	return output, code, err
+}
+
+// RunBytes executes the command on the inner executor and records the result. The output is recorded as string, so binary output is only preserved if it is valid UTF-8.
+func (e *RecordingExecutor) RunBytes(command string, args ...string) ([]byte, int, errors.Error) {
+	output, code, err := e.inner.RunBytes(command, args...)
+	e.record(newInteraction(GetCommandLine(command, args...), string(output), code, err))
+	return output, code, err
+}
+
+func (e *RecordingExecutor) record(interaction Interaction) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.interactions = append(e.interactions, interaction)
+}
+
+// Interactions returns all recorded interactions in order of completion.
+func (e *RecordingExecutor) Interactions() []Interaction {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	return append([]Interaction{}, e.interactions...)
+}
+
+// Save writes all recorded interactions to a JSON cassette file, which is created or truncated.
+func (e *RecordingExecutor) Save(file string) errors.Error {
+	data, err := json.MarshalIndent(e.Interactions(), "", "\t")
+	if err != nil {
+		return ErrCassette.Make().Cause(err)
+	}
+	if err := ioutil.WriteFile(file, data, 0644); err != nil {
+		return ErrCassette.Make().Cause(err)
+	}
+	return nil
+}
+
+// NewRecordingExecutor returns an executor that records all commands run on inner.
+func NewRecordingExecutor(inner Executor) *RecordingExecutor {
+	return &RecordingExecutor{inner: inner}
+}
+
+// ReplayExecutor returns recorded results for command lines without executing anything. Command lines are matched in the normalized form returned by GetCommandLine. Interactions with the same command line are replayed in recorded order, and the last one is repeated as soon as all have been replayed. It is safe for concurrent use.
+type ReplayExecutor struct {
+	mutex        sync.Mutex
+	interactions map[string][]Interaction
+}
+
+// RunLine parses the command line and returns the recorded result.
+func (e *ReplayExecutor) RunLine(commandLine string) (string, int, errors.Error) {
+	return runParsed(e, commandLine)
+}
+
+// Run returns the next recorded result for the command or ErrNoRecording if the command has not been recorded.
+func (e *ReplayExecutor) Run(command string, args ...string) (string, int, errors.Error) {
+	commandLine := GetCommandLine(command, args...)
+	interaction, ok := e.next(commandLine)
+	if !ok {
+		return "", 0, ErrNoRecording.Args(commandLine).Make()
+	}
+	return interaction.Output, interaction.ExitCode, interaction.replayError()
+}
+
+// RunBytes returns the next recorded result for the command or ErrNoRecording if the command has not been recorded.
+func (e *ReplayExecutor) RunBytes(command string, args ...string) ([]byte, int, errors.Error) {
+	output, code, err := e.Run(command, args...)
+	if err != nil && errors.InstanceOf(err, ErrNoRecording) {
+		return nil, code, err
+	}
+	return []byte(output), code, err
+}
+
+// next returns the next interaction for commandLine and keeps the last one.
+func (e *ReplayExecutor) next(commandLine string) (Interaction, bool) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	interactions := e.interactions[commandLine]
+	if len(interactions) == 0 {
+		return Interaction{}, false
+	}
+	if len(interactions) > 1 {
+		e.interactions[commandLine] = interactions[1:]
+	}
+	return interactions[0], true
+}
+
+// NewReplayExecutor returns an executor that replays the given interactions. The command lines are normalized using Parse and GetCommandLine, so cassettes may be edited using any equivalent quoting.
+func NewReplayExecutor(interactions []Interaction) (*ReplayExecutor, errors.Error) {
+	normalized := make(map[string][]Interaction)
+	for _, interaction := range interactions {
+		command, args, err := Parse(interaction.CommandLine)
+		if err != nil {
+			return nil, err
+		}
+		commandLine := GetCommandLine(command, args...)
+		normalized[commandLine] = append(normalized[commandLine], interaction)
+	}
+	return &ReplayExecutor{interactions: normalized}, nil
+}
+
+// LoadReplayExecutor returns an executor that replays the interactions of a JSON cassette file written by RecordingExecutor.Save.
+func LoadReplayExecutor(file string) (*ReplayExecutor, errors.Error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, ErrCassette.Make().Cause(err)
+	}
+
+	var interactions []Interaction
+	if err := json.Unmarshal(data, &interactions); err != nil {
+		return nil, ErrCassette.Make().Cause(err)
+	}
+	return NewReplayExecutor(interactions)
+}
@@ -0,0 +1,139 @@
+package exec
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sbreitf1/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+/* ############################################# */
+/* ###           RecordingExecutor           ### */
+/* ############################################# */
+
+func TestRecordingExecutor(t *testing.T) {
+	e := NewRecordingExecutor(NewLocalExecutor())
+
+	out, code, err := e.Run("echo", "foo bar")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "foo bar\n", out)
+	_, _, err = e.RunLine(Quote(path("fail.sh")))
+	assert.NoError(t, err)
+	_, _, err = e.RunBytes("unknown-command-that-does-not-exist")
+	assert.True(t, errors.InstanceOf(err, ErrCommandNotFound))
+	_, _, err = e.RunLine(`echo "unterminated`)
+	assert.True(t, errors.InstanceOf(err, ErrParse))
+
+	interactions := e.Interactions()
+	if assert.Len(t, interactions, 3) {
+		assert.Equal(t, Interaction{CommandLine: `echo foo\ bar`, Output: "foo bar\n", ExitCode: 0}, interactions[0])
+		assert.Equal(t, GetCommandLine(path("fail.sh")), interactions[1].CommandLine)
+		assert.Equal(t, 1, interactions[1].ExitCode)
+		assert.Equal(t, "run", interactions[2].ErrorKind)
+		assert.Contains(t, interactions[2].Error, "unknown-command-that-does-not-exist")
+	}
+}
+
+func TestRecordingExecutorSaveAndReplay(t *testing.T) {
+	cassette := filepath.Join(t.TempDir(), "cassette.json")
+	recorder := NewRecordingExecutor(NewLocalExecutor(WithTimeout(100 * time.Millisecond)))
+	recorder.Run("echo", "foo bar")
+	recorder.Run(path("fail.sh"))
+	recorder.Run(path("ready.sh"))
+	recorder.Run("unknown-command-that-does-not-exist")
+	assert.NoError(t, recorder.Save(cassette))
+
+	e, err := LoadReplayExecutor(cassette)
+	assert.NoError(t, err)
+
+	out, code, err := e.RunLine(`echo "foo bar"`)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "foo bar\n", out)
+
+	out, code, err = e.Run(path("fail.sh"))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, code)
+	assert.Equal(t, recorder.Interactions()[1].Output, out)
+	assert.NotEmpty(t, out)
+
+	// recorded errors are replayed with their kind
+	out, _, err = e.Run(path("ready.sh"))
+	assert.True(t, errors.InstanceOf(err, ErrTimeout))
+	assert.Equal(t, "starting\n", out)
+	bytes, _, err := e.RunBytes("unknown-command-that-does-not-exist")
+	assert.Equal(t, KindRun, ErrorKind(err))
+	assert.Empty(t, bytes)
+}
+
+/* ############################################# */
+/* ###            ReplayExecutor             ### */
+/* ############################################# */
+
+func TestReplayExecutorSequence(t *testing.T) {
+	e, err := NewReplayExecutor([]Interaction{
+		{CommandLine: "status", Output: "pending"},
+		{CommandLine: "other", Output: "other"},
+		{CommandLine: `"status"`, Output: "done"},
+	})
+	assert.NoError(t, err)
+
+	out, _, _ := e.Run("status")
+	assert.Equal(t, "pending", out)
+	out, _, _ = e.Run("status")
+	assert.Equal(t, "done", out)
+	// the last interaction is repeated
+	out, _, _ = e.Run("status")
+	assert.Equal(t, "done", out)
+}
+
+func TestReplayExecutorUnmatched(t *testing.T) {
+	e, err := NewReplayExecutor([]Interaction{{CommandLine: "echo foo", Output: "foo\n"}})
+	assert.NoError(t, err)
+
+	_, _, err = e.Run("echo", "bar")
+	assert.True(t, errors.InstanceOf(err, ErrNoRecording))
+	assert.Contains(t, err.Error(), "echo bar")
+
+	_, _, err = e.RunBytes("rm", "-rf", "/")
+	assert.True(t, errors.InstanceOf(err, ErrNoRecording))
+}
+
+func TestReplayExecutorErrors(t *testing.T) {
+	e, err := NewReplayExecutor([]Interaction{
+		{CommandLine: "strict", Output: "failed", ExitCode: 2, Error: "Process returned with code 2", ErrorKind: "exit"},
+		{CommandLine: "custom", Error: "something else", ErrorKind: "other"},
+		{CommandLine: "fatal", Output: "panic", Error: `Command output matched fatal pattern "panic"`, ErrorKind: "exit"},
+	})
+	assert.NoError(t, err)
+
+	out, code, err := e.Run("strict")
+	assert.True(t, errors.InstanceOf(err, ErrReturnCode))
+	assert.Equal(t, 2, code)
+	assert.Equal(t, "failed", out)
+
+	_, _, err = e.Run("custom")
+	assert.True(t, errors.InstanceOf(err, ErrRecorded))
+	assert.Contains(t, err.Error(), "something else")
+
+	_, code, err = e.Run("fatal")
+	assert.True(t, errors.InstanceOf(err, ErrRecorded))
+	assert.Equal(t, 0, code)
+}
+
+func TestLoadReplayExecutorInvalid(t *testing.T) {
+	_, err := LoadReplayExecutor(path("missing.json"))
+	assert.True(t, errors.InstanceOf(err, ErrCassette))
+
+	_, err = LoadReplayExecutor(path("broken.json"))
+	assert.True(t, errors.InstanceOf(err, ErrCassette))
+
+	file := filepath.Join(t.TempDir(), "cassette.json")
+	ioutil.WriteFile(file, []byte(`[{"command_line": "echo \"unterminated"}]`), 0644)
+	_, err = LoadReplayExecutor(file)
+	assert.True(t, errors.InstanceOf(err, ErrParse))
+}
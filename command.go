@@ -0,0 +1,46 @@
+package exec
+
+import (
+	"github.com/sbreitf1/errors"
+)
+
+// CommandTemplate holds a base command with fixed arguments that is run with varying additional arguments.
+type CommandTemplate struct {
+	executor Executor
+	command  string
+	args     []string
+}
+
+// Run executes the base command with the fixed arguments followed by extraArgs.
+func (t *CommandTemplate) Run(extraArgs ...string) (string, int, errors.Error) {
+	return t.executor.Run(t.command, t.argsWith(extraArgs)...)
+}
+
+// RunBytes executes the base command with the fixed arguments followed by extraArgs and returns the unmodified output bytes.
+func (t *CommandTemplate) RunBytes(extraArgs ...string) ([]byte, int, errors.Error) {
+	return t.executor.RunBytes(t.command, t.argsWith(extraArgs)...)
+}
+
+// Spec returns the command that would be executed by Run for extraArgs.
+func (t *CommandTemplate) Spec(extraArgs ...string) CommandSpec {
+	return CommandSpec{Command: t.command, Args: t.argsWith(extraArgs)}
+}
+
+// String returns the quoted command line of the base command with its fixed arguments.
+func (t *CommandTemplate) String() string {
+	return GetCommandLine(t.command, t.args...)
+}
+
+func (t *CommandTemplate) argsWith(extraArgs []string) []string {
+	args := make([]string, 0, len(t.args)+len(extraArgs))
+	args = append(args, t.args...)
+	return append(args, extraArgs...)
+}
+
+// NewCommandTemplate returns a template for command with fixed args that is executed using e. The DefaultExecutor is used if e is nil.
+func NewCommandTemplate(e Executor, command string, args ...string) *CommandTemplate {
+	if e == nil {
+		e = DefaultExecutor
+	}
+	return &CommandTemplate{e, command, append([]string{}, args...)}
+}
@@ -0,0 +1,50 @@
+package exec
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sbreitf1/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+/* ############################################# */
+/* ###            CommandTemplate            ### */
+/* ############################################# */
+
+func TestCommandTemplateRun(t *testing.T) {
+	tpl := NewCommandTemplate(NewLocalExecutor(), path("args.sh"), "foo bar")
+	out, code, err := tpl.Run("baz")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, code)
+	assert.True(t, strings.Contains(out, "1foo bar ; 2baz"))
+
+	out, _, err = tpl.Run()
+	assert.NoError(t, err)
+	assert.True(t, strings.Contains(out, "1foo bar ; 2"))
+}
+
+func TestCommandTemplateMock(t *testing.T) {
+	var calls []CommandSpec
+	e := NewMockExecutor(func(command string, args ...string) (string, int, errors.Error) {
+		calls = append(calls, CommandSpec{command, args})
+		return "", 0, nil
+	})
+
+	tpl := NewCommandTemplate(e, "kubectl", "--context", "prod")
+	tpl.Run("get", "pods")
+	tpl.RunBytes("get", "nodes")
+	assert.Equal(t, []CommandSpec{
+		{"kubectl", []string{"--context", "prod", "get", "pods"}},
+		{"kubectl", []string{"--context", "prod", "get", "nodes"}},
+	}, calls)
+}
+
+func TestCommandTemplateSpec(t *testing.T) {
+	args := []string{"--context", "prod"}
+	tpl := NewCommandTemplate(nil, "kubectl", args...)
+	args[1] = "dev"
+
+	assert.Equal(t, CommandSpec{"kubectl", []string{"--context", "prod", "get"}}, tpl.Spec("get"))
+	assert.Equal(t, "kubectl --context prod", tpl.String())
+}
@@ -0,0 +1,124 @@
+package exec
+
+import (
+	"strings"
+
+	"github.com/sbreitf1/errors"
+)
+
+// ConditionalOp denotes the operator that joins a command to the previous one in a conditional command line.
+type ConditionalOp string
+
+const (
+	// CondAnd runs the command only if the previous command returned with exit code 0 (&&).
+	CondAnd ConditionalOp = "&&"
+	// CondOr runs the command only if the previous command returned with a non-zero exit code (||).
+	CondOr ConditionalOp = "||"
+)
+
+// ConditionalCommand denotes a single command of a conditional command line. Op joins the command to the previous one and is empty for the first command.
+type ConditionalCommand struct {
+	Op      ConditionalOp
+	Command string
+	Args    []string
+}
+
+// Conditional denotes a list of commands joined by && and ||. Both operators have the same precedence and are evaluated from left to right like in a shell, so "a && b || c" runs c if either a or b failed.
+type Conditional struct {
+	Commands []ConditionalCommand
+}
+
+var conditionalOperators = []string{string(CondAnd), string(CondOr)}
+
+// ParseConditional parses a command line with commands joined by && and || into a structured representation without executing anything. Quoted or escaped operators are kept literally.
+func ParseConditional(commandLine string) (*Conditional, errors.Error) {
+	tokens, err := tokenize(commandLine, DefaultParseConfig(), conditionalOperators)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, newParseError(ParseErrorEmptyInput, "Unexpected end of command line")
+	}
+
+	conditional := &Conditional{Commands: make([]ConditionalCommand, 0)}
+	var op ConditionalOp
+	var words []string
+	finishCommand := func() errors.Error {
+		if len(words) == 0 {
+			return newParseError(ParseErrorSyntax, "Missing command in conditional command line")
+		}
+		command := ConditionalCommand{Op: op, Command: words[0]}
+		if len(words) > 1 {
+			command.Args = words[1:]
+		}
+		conditional.Commands = append(conditional.Commands, command)
+		words = nil
+		return nil
+	}
+
+	for _, t := range tokens {
+		if !t.operator {
+			words = append(words, t.value)
+			continue
+		}
+		if err := finishCommand(); err != nil {
+			return nil, err
+		}
+		op = ConditionalOp(t.value)
+	}
+	if err := finishCommand(); err != nil {
+		return nil, err
+	}
+	return conditional, nil
+}
+
+// ConditionalExecutor evaluates command lines with && and || on an inner Executor without involving a shell, so all arguments keep the quoting guarantees of Parse.
+type ConditionalExecutor struct {
+	inner Executor
+}
+
+// RunLine parses the command line using ParseConditional and evaluates it using RunConditional.
+func (e *ConditionalExecutor) RunLine(commandLine string) (string, int, errors.Error) {
+	conditional, err := ParseConditional(commandLine)
+	if err != nil {
+		return "", 0, err
+	}
+
+	return e.RunConditional(conditional)
+}
+
+// RunConditional runs the commands of c from left to right on the inner executor and skips every command whose condition is not met by the exit code of the last executed command. The returned output is the concatenated output of all executed commands and the exit code is the one of the last executed command.
+//
+// Evaluation stops at the first command that returns an error, for example because it could not be started, and the error is returned along with the output so far.
+func (e *ConditionalExecutor) RunConditional(c *Conditional) (string, int, errors.Error) {
+	var sb strings.Builder
+	code := 0
+	for i, command := range c.Commands {
+		if i > 0 && (command.Op == CondAnd) != (code == 0) {
+			continue
+		}
+
+		output, commandCode, err := e.inner.Run(command.Command, command.Args...)
+		sb.WriteString(output)
+		code = commandCode
+		if err != nil {
+			return sb.String(), code, err
+		}
+	}
+	return sb.String(), code, nil
+}
+
+// Run executes a single command with separated arguments on the inner executor.
+func (e *ConditionalExecutor) Run(command string, args ...string) (string, int, errors.Error) {
+	return e.inner.Run(command, args...)
+}
+
+// RunBytes executes a single command with separated arguments on the inner executor and returns the unmodified output bytes.
+func (e *ConditionalExecutor) RunBytes(command string, args ...string) ([]byte, int, errors.Error) {
+	return e.inner.RunBytes(command, args...)
+}
+
+// NewConditionalExecutor returns an executor that evaluates && and || in command lines passed to RunLine and runs the commands on inner.
+func NewConditionalExecutor(inner Executor) *ConditionalExecutor {
+	return &ConditionalExecutor{inner}
+}
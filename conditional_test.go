@@ -0,0 +1,141 @@
+package exec
+
+import (
+	"testing"
+
+	"github.com/sbreitf1/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+/* ############################################# */
+/* ###           ParseConditional            ### */
+/* ############################################# */
+
+func TestParseConditional(t *testing.T) {
+	c, err := ParseConditional(`make build && make test || ./rollback.sh "last release"`)
+	assert.NoError(t, err)
+	assert.Equal(t, &Conditional{Commands: []ConditionalCommand{
+		{Command: "make", Args: []string{"build"}},
+		{Op: CondAnd, Command: "make", Args: []string{"test"}},
+		{Op: CondOr, Command: "./rollback.sh", Args: []string{"last release"}},
+	}}, c)
+}
+
+func TestParseConditionalNoSpaces(t *testing.T) {
+	c, err := ParseConditional(`a&&b||c`)
+	assert.NoError(t, err)
+	assert.Equal(t, &Conditional{Commands: []ConditionalCommand{
+		{Command: "a"},
+		{Op: CondAnd, Command: "b"},
+		{Op: CondOr, Command: "c"},
+	}}, c)
+}
+
+func TestParseConditionalQuoted(t *testing.T) {
+	c, err := ParseConditional(`echo "a && b" '||' \&\& c\|\| && true`)
+	assert.NoError(t, err)
+	assert.Equal(t, &Conditional{Commands: []ConditionalCommand{
+		{Command: "echo", Args: []string{"a && b", "||", "&&", "c||"}},
+		{Op: CondAnd, Command: "true"},
+	}}, c)
+}
+
+func TestParseConditionalErrors(t *testing.T) {
+	_, err := ParseConditional(``)
+	assert.Equal(t, ParseErrorEmptyInput, ParseErrorKindOf(err))
+	_, err = ParseConditional(`&& b`)
+	assert.Equal(t, ParseErrorSyntax, ParseErrorKindOf(err))
+	_, err = ParseConditional(`a && || b`)
+	assert.Equal(t, ParseErrorSyntax, ParseErrorKindOf(err))
+	_, err = ParseConditional(`a ||`)
+	assert.Equal(t, ParseErrorSyntax, ParseErrorKindOf(err))
+	_, err = ParseConditional(`a && "b`)
+	assert.Equal(t, ParseErrorUnterminatedDoubleQuote, ParseErrorKindOf(err))
+}
+
+func TestParseConditionalRoundTrip(t *testing.T) {
+	args := []string{"x&&rm", "a||b", "&", "|", "a;b", "&&", "out>f", "<in", "2>x"}
+	for _, style := range []QuoteStyle{StyleAuto, StyleRaw, StyleReadable} {
+		c, err := ParseConditional(GetCommandLineStyle(style, "echo", args...))
+		assert.NoError(t, err)
+		assert.Equal(t, &Conditional{Commands: []ConditionalCommand{{Command: "echo", Args: args}}}, c)
+	}
+}
+
+/* ############################################# */
+/* ###          ConditionalExecutor          ### */
+/* ############################################# */
+
+func TestConditionalExecutorShortCircuit(t *testing.T) {
+	e := NewConditionalExecutor(NewLocalExecutor())
+
+	out, code, err := e.RunLine(`echo build && echo test || echo rollback`)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "build\ntest\n", out)
+
+	out, code, err = e.RunLine(`echo build && false && echo test || echo rollback`)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "build\nrollback\n", out)
+
+	out, code, err = e.RunLine(`false || echo fallback && echo after`)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "fallback\nafter\n", out)
+}
+
+func TestConditionalExecutorExitCode(t *testing.T) {
+	e := NewConditionalExecutor(NewLocalExecutor())
+
+	// the exit code of the last executed command is returned
+	out, code, err := e.RunLine(`echo a && false && echo b`)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, code)
+	assert.Equal(t, "a\n", out)
+
+	_, code, err = e.RunLine(`true || false`)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, code)
+
+	_, code, err = e.RunLine(Quote(path("fail.sh")) + ` || false`)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, code)
+}
+
+func TestConditionalExecutorQuotedOperators(t *testing.T) {
+	mock := NewMockExecutor(nil)
+	e := NewConditionalExecutor(mock)
+
+	_, _, err := e.RunLine(`echo "a && b; rm -rf /" '||' && echo $(id)`)
+	assert.NoError(t, err)
+	assert.Equal(t, []CommandSpec{
+		{Command: "echo", Args: []string{"a && b; rm -rf /", "||"}},
+		{Command: "echo", Args: []string{"$(id)"}},
+	}, mock.Calls())
+}
+
+func TestConditionalExecutorQuotedArgs(t *testing.T) {
+	mock := NewMockExecutor(nil)
+	e := NewConditionalExecutor(mock)
+
+	_, _, err := e.RunLine(GetCommandLine("echo", "x&&rm", "-rf", "/"))
+	assert.NoError(t, err)
+	assert.Equal(t, []CommandSpec{{Command: "echo", Args: []string{"x&&rm", "-rf", "/"}}}, mock.Calls())
+}
+
+func TestConditionalExecutorError(t *testing.T) {
+	mock := NewMockExecutor(nil)
+	mock.ReturnSequence("a", MockResult{Output: "partial", ExitCode: 0})
+	mock.ReturnSequence("b", MockResult{Err: ErrRun.Make()})
+	e := NewConditionalExecutor(mock)
+
+	// evaluation stops at errors, even if a later command would handle failures
+	out, _, err := e.RunLine(`a && b || c`)
+	assert.True(t, errors.InstanceOf(err, ErrRun))
+	assert.Equal(t, "partial", out)
+	assert.Len(t, mock.Calls(), 2)
+
+	_, _, err = e.RunLine(`a &&`)
+	assert.Equal(t, ParseErrorSyntax, ParseErrorKindOf(err))
+}
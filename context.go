@@ -0,0 +1,52 @@
+package exec
+
+import (
+	"context"
+
+	"github.com/sbreitf1/errors"
+)
+
+// ContextExecutor runs all commands on an inner Executor using a context bound at construction. Commands are stopped when the context is cancelled if the inner executor implements ContextRunner, like LocalExecutor does. Otherwise commands are only prevented from starting after cancellation.
+type ContextExecutor struct {
+	ctx   context.Context
+	inner Executor
+}
+
+// RunLine runs the command line on the inner executor and returns ErrCanceled if the bound context is cancelled or ErrTimeout if its deadline is exceeded. Only LocalExecutor is stopped when the context is cancelled, other executors are only prevented from starting.
+func (e *ContextExecutor) RunLine(commandLine string) (string, int, errors.Error) {
+	if local, ok := e.inner.(*LocalExecutor); ok {
+		return local.RunLineContext(e.ctx, commandLine)
+	}
+
+	if err := e.ctx.Err(); err != nil {
+		return "", 0, contextError(err)
+	}
+	return e.inner.RunLine(commandLine)
+}
+
+// Run executes the command on the inner executor and returns ErrCanceled if the bound context is cancelled or ErrTimeout if its deadline is exceeded.
+func (e *ContextExecutor) Run(command string, args ...string) (string, int, errors.Error) {
+	return runContext(e.inner, e.ctx, command, args...)
+}
+
+// RunBytes executes the command on the inner executor and returns the unmodified output bytes. ErrCanceled or ErrTimeout is returned if the bound context is done.
+func (e *ContextExecutor) RunBytes(command string, args ...string) ([]byte, int, errors.Error) {
+	if local, ok := e.inner.(*LocalExecutor); ok {
+		// Run returns the processed output, which must not be passed off as raw bytes
+		return local.RunBytesContext(e.ctx, command, args...)
+	}
+	if _, ok := e.inner.(ContextRunner); ok {
+		output, code, err := e.Run(command, args...)
+		return []byte(output), code, err
+	}
+
+	if err := e.ctx.Err(); err != nil {
+		return nil, 0, contextError(err)
+	}
+	return e.inner.RunBytes(command, args...)
+}
+
+// NewContextExecutor returns an executor that runs all commands on inner and stops them when ctx is cancelled.
+func NewContextExecutor(ctx context.Context, inner Executor) *ContextExecutor {
+	return &ContextExecutor{ctx, inner}
+}
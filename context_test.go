@@ -0,0 +1,71 @@
+package exec
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sbreitf1/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+/* ############################################# */
+/* ###            ContextExecutor            ### */
+/* ############################################# */
+
+func TestContextExecutorRun(t *testing.T) {
+	e := NewContextExecutor(context.Background(), NewLocalExecutor())
+	out, code, err := e.RunLine(Quote(path("args.sh")) + ` "foo test space" bar`)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, code)
+	assert.True(t, strings.Contains(out, "1foo test space ; 2bar"))
+}
+
+func TestContextExecutorCancel(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	e := NewContextExecutor(ctx, NewLocalExecutor())
+	_, _, err := e.Run(path("signal.sh"))
+	assert.True(t, errors.InstanceOf(err, ErrTimeout))
+	_, _, err = e.RunBytes(path("success.sh"))
+	assert.True(t, errors.InstanceOf(err, ErrTimeout))
+}
+
+func TestContextExecutorRunBytesRaw(t *testing.T) {
+	inner := NewLocalExecutor(WithStripANSI(true), WithOutputFilter(strings.ToUpper))
+	e := NewContextExecutor(context.Background(), inner)
+	out, _, err := e.RunBytes("printf", `\033[1mbold\033[0m\n`)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("\x1b[1mbold\x1b[0m\n"), out)
+
+	str, _, err := e.Run("printf", `\033[1mbold\033[0m\n`)
+	assert.NoError(t, err)
+	assert.Equal(t, "BOLD\n", str)
+}
+
+func TestContextExecutorRunBytesCancel(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, _, err := NewContextExecutor(ctx, NewLocalExecutor()).RunBytes(path("signal.sh"))
+	assert.True(t, errors.InstanceOf(err, ErrTimeout))
+	assert.True(t, time.Since(start) < 2*time.Second)
+}
+
+func TestContextExecutorNoContextRunner(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	inner := NewDryRunExecutor(0)
+	e := NewContextExecutor(ctx, inner)
+
+	_, _, err := e.Run("foo")
+	assert.NoError(t, err)
+	cancel()
+	_, _, err = e.Run("bar")
+	assert.True(t, errors.InstanceOf(err, ErrCanceled))
+	_, _, err = e.RunBytes("bar")
+	assert.True(t, errors.InstanceOf(err, ErrCanceled))
+	assert.Equal(t, 1, len(inner.Commands()))
+}
@@ -0,0 +1,49 @@
+package exec
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+
+	"github.com/sbreitf1/errors"
+)
+
+var (
+	// ErrCredential occurs when a command could not be run with the credential configured by WithCredential.
+	ErrCredential = errors.New("Could not run command as uid %d and gid %d")
+)
+
+// invalidID denotes the unsigned representation of -1, which is reserved and cannot be used as user or group id.
+const invalidID = ^uint32(0)
+
+// credential holds the user and groups to run commands as.
+type credential struct {
+	uid    uint32
+	gid    uint32
+	groups []uint32
+}
+
+// apply validates the credential and configures cmd to use it.
+func (c *credential) apply(cmd *exec.Cmd) errors.Error {
+	if c.uid == invalidID || c.gid == invalidID {
+		return c.error().StrCause("Invalid user or group id")
+	}
+	for _, group := range c.groups {
+		if group == invalidID {
+			return c.error().StrCause("Invalid supplementary group id")
+		}
+	}
+	return setCredential(cmd, c)
+}
+
+func (c *credential) error() errors.Error {
+	return ErrCredential.Args(c.uid, c.gid).Make()
+}
+
+// isNotPermitted returns true if err has been caused by missing privileges to change the credential.
+func isNotPermitted(err error) bool {
+	if e, ok := err.(*os.PathError); ok {
+		err = e.Err
+	}
+	return err == syscall.EPERM
+}
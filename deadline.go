@@ -0,0 +1,33 @@
+package exec
+
+import (
+	"context"
+	"time"
+
+	"github.com/sbreitf1/errors"
+)
+
+// RunWithDeadline executes a command line with separated arguments and kills the process if it is still running at deadline. ErrTimeout is returned in this case or immediately without starting the process if deadline has already passed.
+func (e *LocalExecutor) RunWithDeadline(deadline time.Time, command string, args ...string) (string, int, errors.Error) {
+	return runWithDeadline(e, deadline, command, args...)
+}
+
+// RunWithDeadline executes a command with given arguments using the DefaultExecutor and stops it at deadline. Executors that do not implement ContextRunner are only prevented from starting after deadline.
+func RunWithDeadline(deadline time.Time, command string, args ...string) (string, int, errors.Error) {
+	return runWithDeadline(DefaultExecutor, deadline, command, args...)
+}
+
+func runWithDeadline(e Executor, deadline time.Time, command string, args ...string) (string, int, errors.Error) {
+	if !time.Now().Before(deadline) {
+		return "", 0, ErrTimeout.Make().Cause(context.DeadlineExceeded)
+	}
+
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+
+	output, code, err := runContext(e, ctx, command, args...)
+	if errors.InstanceOf(err, ErrCanceled) {
+		err = ErrTimeout.Make().Cause(ctx.Err())
+	}
+	return output, code, err
+}
@@ -0,0 +1,38 @@
+package exec
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sbreitf1/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+/* ############################################# */
+/* ###            RunWithDeadline            ### */
+/* ############################################# */
+
+func TestRunWithDeadline(t *testing.T) {
+	out, code, err := NewLocalExecutor().RunWithDeadline(time.Now().Add(5*time.Second), path("success.sh"))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, code)
+	assert.True(t, strings.Contains(out, "some test output here"))
+}
+
+func TestRunWithDeadlineExceeded(t *testing.T) {
+	start := time.Now()
+	_, _, err := NewLocalExecutor().RunWithDeadline(start.Add(200*time.Millisecond), path("signal.sh"))
+	assert.True(t, errors.InstanceOf(err, ErrTimeout))
+	assert.True(t, time.Since(start) < 5*time.Second)
+}
+
+func TestRunWithDeadlinePassed(t *testing.T) {
+	defer restoreDefaultExecutor(DefaultExecutor)
+	e := NewDryRunExecutor(0)
+	DefaultExecutor = e
+
+	_, _, err := RunWithDeadline(time.Now().Add(-time.Second), "foo")
+	assert.True(t, errors.InstanceOf(err, ErrTimeout))
+	assert.Empty(t, e.Commands())
+}
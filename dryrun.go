@@ -0,0 +1,47 @@
+package exec
+
+import (
+	"sync"
+
+	"github.com/sbreitf1/errors"
+)
+
+// DryRunExecutor records the command lines of all commands instead of executing them.
+type DryRunExecutor struct {
+	mutex    sync.Mutex
+	commands []string
+	exitCode int
+}
+
+// RunLine parses the command line and records it without execution.
+func (e *DryRunExecutor) RunLine(commandLine string) (string, int, errors.Error) {
+	return runParsed(e, commandLine)
+}
+
+// Run records the command line without execution and returns the configured exit code.
+func (e *DryRunExecutor) Run(command string, args ...string) (string, int, errors.Error) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.commands = append(e.commands, GetCommandLine(command, args...))
+	return "", e.exitCode, nil
+}
+
+// RunBytes records the command line without execution and returns the configured exit code.
+func (e *DryRunExecutor) RunBytes(command string, args ...string) ([]byte, int, errors.Error) {
+	_, code, err := e.Run(command, args...)
+	return nil, code, err
+}
+
+// Commands returns the command lines of all commands that would have been executed in order of invocation.
+func (e *DryRunExecutor) Commands() []string {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	commands := make([]string, len(e.commands))
+	copy(commands, e.commands)
+	return commands
+}
+
+// NewDryRunExecutor returns an executor that only records commands and returns exitCode for every command.
+func NewDryRunExecutor(exitCode int) *DryRunExecutor {
+	return &DryRunExecutor{commands: make([]string, 0), exitCode: exitCode}
+}
@@ -0,0 +1,41 @@
+package exec
+
+import (
+	"testing"
+
+	"github.com/sbreitf1/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+/* ############################################# */
+/* ###            DryRunExecutor             ### */
+/* ############################################# */
+
+func TestDryRunExecutor(t *testing.T) {
+	e := NewDryRunExecutor(0)
+	out, code, err := e.Run("rm", "-rf", "/tmp/some dir")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "", out)
+
+	out, code, err = e.RunLine(`echo "foo bar"`)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "", out)
+
+	assert.Equal(t, []string{`rm -rf /tmp/some\ dir`, `echo foo\ bar`}, e.Commands())
+}
+
+func TestDryRunExecutorExitCode(t *testing.T) {
+	e := NewDryRunExecutor(42)
+	_, code, err := e.Run(path("success.sh"))
+	assert.NoError(t, err)
+	assert.Equal(t, 42, code)
+}
+
+func TestDryRunExecutorParseError(t *testing.T) {
+	e := NewDryRunExecutor(0)
+	_, _, err := e.RunLine(`newcommand "test`)
+	assert.True(t, errors.InstanceOf(err, ErrParse))
+	assert.Len(t, e.Commands(), 0)
+}
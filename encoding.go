@@ -0,0 +1,19 @@
+package exec
+
+import (
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+// decodeOutput transcodes output to UTF-8 according to the output encoding of the executor. The output is returned unmodified if no encoding is configured or it cannot be decoded.
+func (e *LocalExecutor) decodeOutput(output string) string {
+	if e.outputEncoding == nil {
+		return output
+	}
+
+	decoded, _, err := transform.String(unicode.BOMOverride(e.outputEncoding.NewDecoder()), output)
+	if err != nil {
+		return output
+	}
+	return decoded
+}
@@ -0,0 +1,101 @@
+package exec
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/sbreitf1/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+/* ############################################# */
+/* ###         Output on Error: None         ### */
+/* ############################################# */
+
+func TestErrorOutputParse(t *testing.T) {
+	out, _, err := NewLocalExecutor().RunLine(`echo "unterminated`)
+	assert.True(t, errors.InstanceOf(err, ErrParse))
+	assert.Equal(t, "", out)
+
+	out, err = ShouldRunLine(`echo "unterminated`)
+	assert.True(t, errors.InstanceOf(err, ErrParse))
+	assert.Equal(t, "", out)
+}
+
+func TestErrorOutputNotStarted(t *testing.T) {
+	out, _, err := NewLocalExecutor().Run("unknown-command-that-does-not-exist")
+	assert.True(t, errors.InstanceOf(err, ErrCommandNotFound))
+	assert.Equal(t, "", out)
+
+	out, _, err = NewLocalExecutor().Run(path("noexec.txt"))
+	assert.True(t, errors.InstanceOf(err, ErrPermissionDenied))
+	assert.Equal(t, "", out)
+
+	out, _, err = NewLocalExecutor(WithCleanEnv(nil)).RunLine("unknown-command-that-does-not-exist")
+	assert.True(t, errors.InstanceOf(err, ErrCommandNotFound))
+	assert.Equal(t, "", out)
+}
+
+func TestErrorOutputContextDoneBeforeStart(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	out, _, err := NewLocalExecutor().RunContext(ctx, path("success.sh"))
+	assert.True(t, errors.InstanceOf(err, ErrCanceled))
+	assert.Equal(t, "", out)
+}
+
+/* ############################################# */
+/* ###       Output on Error: Partial        ### */
+/* ############################################# */
+
+func TestErrorOutputTimeout(t *testing.T) {
+	out, _, err := NewLocalExecutor(WithTimeout(100 * time.Millisecond)).Run(path("ready.sh"))
+	assert.True(t, errors.InstanceOf(err, ErrTimeout))
+	assert.Equal(t, "starting\n", out)
+}
+
+func TestErrorOutputIdleTimeout(t *testing.T) {
+	out, _, err := NewLocalExecutor(WithIdleTimeout(300*time.Millisecond), WithProcessGroup()).Run(path("idle.sh"), "hang")
+	assert.True(t, errors.InstanceOf(err, ErrIdleTimeout))
+	assert.Equal(t, "tick 1\ntick 2\ntick 3\ntick 4\ntick 5\n", out)
+}
+
+func TestErrorOutputCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+
+	out, _, err := NewLocalExecutor().RunContext(ctx, path("ready.sh"))
+	assert.True(t, errors.InstanceOf(err, ErrCanceled))
+	assert.Equal(t, "starting\n", out)
+}
+
+func TestErrorOutputFatalOutput(t *testing.T) {
+	out, _, err := NewLocalExecutor(WithFatalOutputPatterns(regexp.MustCompile("work"))).Run(path("sentinel.sh"), "0")
+	assert.True(t, errors.InstanceOf(err, ErrFatalOutput))
+	assert.Equal(t, "doing some work\nEXIT:0\n", out)
+}
+
+func TestErrorOutputPipelineCanceled(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	out, _, err := NewLocalExecutor().RunPipelineContext(ctx, CommandSpec{Command: path("ready.sh")}, CommandSpec{Command: "cat"})
+	assert.True(t, errors.InstanceOf(err, ErrTimeout))
+	assert.Equal(t, "starting\n", out)
+}
+
+func TestErrorOutputShouldRun(t *testing.T) {
+	defer restoreDefaultExecutor(DefaultExecutor)
+	DefaultExecutor = NewLocalExecutor(WithTimeout(100 * time.Millisecond))
+
+	out, err := ShouldRun(path("ready.sh"))
+	assert.True(t, errors.InstanceOf(err, ErrTimeout))
+	assert.Equal(t, "starting\n", out)
+
+	out, err = ShouldRunLine(Quote(path("ready.sh")))
+	assert.True(t, errors.InstanceOf(err, ErrTimeout))
+	assert.Equal(t, "starting\n", out)
+}
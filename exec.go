@@ -1,12 +1,22 @@
 package exec
 
 import (
+	"context"
+	"fmt"
+	"io"
+	"os"
 	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
 	"strings"
+	"sync"
 	"syscall"
+	"time"
 	"unicode"
 
 	"github.com/sbreitf1/errors"
+	"golang.org/x/text/encoding"
 )
 
 const (
@@ -14,17 +24,37 @@ const (
 	esc = '\\'
 	sqt = '\''
 	dqt = '"'
+	dlr = '$'
 )
 
+// operatorRunes contains all runes that start an operator recognized by ParseSequence, ParsePipeline or ParseConditional. They are escaped by QuoteRaw, so quoted arguments are never split at operators.
+const operatorRunes = ";|&<>"
+
 var (
-	// ErrRun occurs when a command could not be executed.
+	// ErrRun occurs when a command could not be executed. Common start failures are reported using the more specific ErrCommandNotFound, ErrPermissionDenied, ErrArgsTooLong and ErrBadInterpreter instead, which are no instances of ErrRun. Use ErrorKind(err) == KindRun to check for all errors of commands that could not be executed.
 	ErrRun = errors.New("Could not execute command")
-	// ErrReturnCode occurs when a command was executed but returned with a non-zero exit code.
+	// ErrReturnCode occurs when a command was executed but returned with a non-zero exit code. Errors of this type returned by ShouldRun and similar functions are of type *ReturnCodeError and carry the command output.
 	ErrReturnCode = errors.New("Process returned with code %d")
 	// ErrParse occurs when a malformed command line was encountered.
 	ErrParse = errors.New("Unable to parse command line")
+	// ErrCanceled occurs when a running command has been stopped because its context was cancelled manually.
+	ErrCanceled = errors.New("Command execution has been canceled")
+	// ErrTimeout occurs when a running command has been stopped because it exceeded its time limit or the deadline of its context.
+	ErrTimeout = errors.New("Command execution timed out")
+	// ErrIdleTimeout occurs when a running command has been stopped because it did not produce any output for too long.
+	ErrIdleTimeout = errors.New("Command did not produce output for %s")
+	// ErrFatalOutput occurs when the output of a command matched a fatal pattern.
+	ErrFatalOutput = errors.New("Command output matched fatal pattern %q")
+	// ErrNiceness occurs when the priority of a started process could not be set. The process is killed in this case.
+	ErrNiceness = errors.New("Could not set niceness %d of process")
+	// ErrSignal occurs when a signal could not be delivered to a running process.
+	ErrSignal = errors.New("Could not send signal to process")
+	// ErrUnexpectedCommand is returned by a strict MockExecutor for commands that have not been expected.
+	ErrUnexpectedCommand = errors.New("Unexpected command %s")
 	// DefaultExecutor denotes the Executor that is used by default for Run and RunLine commands.
 	DefaultExecutor Executor
+
+	cLocale = map[string]string{"LC_ALL": "C", "LANG": "C"}
 )
 
 func init() {
@@ -32,35 +62,581 @@ func init() {
 }
 
 // Executor represents the interface for shell command execution.
+//
+// All executors of this package follow the same contract for errors: If a process has been started, the output captured so far is returned along with the error, which includes timeouts, cancellation, fatal output and errors while waiting for the process. If no process has been started, for example because the command line could not be parsed, the command could not be found or the context was done before, the output is empty.
 type Executor interface {
 	// RunLine executes an escaped single string command line.
 	RunLine(commandLine string) (string, int, errors.Error)
 	// Run executes a command line with separated arguments.
 	Run(command string, args ...string) (string, int, errors.Error)
+	// RunBytes executes a command line with separated arguments and returns the unmodified output bytes.
+	RunBytes(command string, args ...string) ([]byte, int, errors.Error)
+}
+
+// ContextRunner is implemented by executors that are able to stop commands when a context is cancelled.
+type ContextRunner interface {
+	// RunContext executes a command line with separated arguments and stops the process when ctx is cancelled.
+	RunContext(ctx context.Context, command string, args ...string) (string, int, errors.Error)
 }
 
 // LocalExecutor is used to execute commands on the local shell.
 type LocalExecutor struct {
+	dir            string
+	env            map[string]string
+	cleanEnv       bool
+	envAssignments bool
+	timeout        time.Duration
+	idleTimeout    time.Duration
+	maxOutput      int
+	maxStdout      int
+	maxStderr      int
+	tailOutput     int
+	outputHint     int
+	fatalPatterns  []*regexp.Regexp
+	processGroup   bool
+	shutdownSignal os.Signal
+	shutdownGrace  time.Duration
+	exitCodeParser ExitCodeParser
+	outputEncoding encoding.Encoding
+	outputFilters  []func(string) string
+	stripANSI      bool
+	normalizeNL    bool
+	credential     *credential
+	niceness       *int
+	configurators  []func(*exec.Cmd)
+	extraFiles     []*os.File
+	stderr         io.Writer
+	trace          io.Writer
+	ptyCols        uint16
+	ptyRows        uint16
+}
+
+// LocalOption denotes a configuration option for a LocalExecutor.
+type LocalOption func(e *LocalExecutor)
+
+// ExitCodeParser derives the exit code of a command from its output. It returns false if the output does not contain an exit code.
+type ExitCodeParser func(output string) (int, bool)
+
+// WithEnv sets additional environment variables for all commands. Variables of the current process are inherited and overwritten by vars, unless the inherited environment has been dropped using WithCleanEnv.
+func WithEnv(vars map[string]string) LocalOption {
+	return func(e *LocalExecutor) {
+		if e.env == nil {
+			e.env = make(map[string]string)
+		}
+		for key, value := range vars {
+			e.env[key] = value
+		}
+	}
+}
+
+// WithCleanEnv runs all commands with exactly the given environment variables and ignores the environment of the current process. PATH is set to a minimal system default like /usr/bin:/bin unless contained in vars, and commands without path separator are looked up using this PATH instead of the PATH of the current process.
+//
+// Precedence follows the order of options: variables set by WithEnv before WithCleanEnv are discarded, while WithEnv applied afterwards adds to or overwrites the clean environment.
+func WithCleanEnv(vars map[string]string) LocalOption {
+	return func(e *LocalExecutor) {
+		e.cleanEnv = true
+		e.env = make(map[string]string)
+		for key, value := range vars {
+			e.env[key] = value
+		}
+	}
+}
+
+// WithDir runs all commands in the working directory dir. Commands run in the working directory of the current process if dir is empty.
+func WithDir(dir string) LocalOption {
+	return func(e *LocalExecutor) {
+		e.dir = dir
+	}
+}
+
+// WithEnvAssignments makes RunLine accept leading variable assignments like FOO=bar ls, which are applied as environment variables for this single command only. See ParseWithEnvAssignments for details.
+func WithEnvAssignments() LocalOption {
+	return func(e *LocalExecutor) {
+		e.envAssignments = true
+	}
+}
+
+// WithTimeout stops commands that are still running after d and returns ErrTimeout in this case. Output written afterwards by child processes that inherited stdout or stderr is not collected and does not delay the result.
+func WithTimeout(d time.Duration) LocalOption {
+	return func(e *LocalExecutor) {
+		e.timeout = d
+	}
+}
+
+// WithIdleTimeout stops commands that did not write to stdout or stderr for d and returns ErrIdleTimeout in this case. The total execution time is not limited. Like with WithTimeout, child processes that still hold stdout or stderr open do not delay the result.
+func WithIdleTimeout(d time.Duration) LocalOption {
+	return func(e *LocalExecutor) {
+		e.idleTimeout = d
+	}
+}
+
+// WithMaxOutput limits the captured output to the first n bytes. The process is not affected and can still produce more output. Streamed output is not limited. This option replaces WithTailBuffer.
+func WithMaxOutput(n int) LocalOption {
+	return func(e *LocalExecutor) {
+		e.maxOutput = n
+		e.tailOutput = 0
+	}
+}
+
+// WithMaxStdout limits stdout captured by RunSplit and ExecuteSplit to the first n bytes independently of stderr. The combined output is not affected, use WithMaxOutput or WithTailBuffer to limit it.
+func WithMaxStdout(n int) LocalOption {
+	return func(e *LocalExecutor) {
+		e.maxStdout = n
+	}
+}
+
+// WithMaxStderr limits stderr captured by RunSplit and ExecuteSplit to the first n bytes independently of stdout. The combined output is not affected, use WithMaxOutput or WithTailBuffer to limit it.
+func WithMaxStderr(n int) LocalOption {
+	return func(e *LocalExecutor) {
+		e.maxStderr = n
+	}
+}
+
+// WithStderr forwards stderr of commands run using RunStdout to w, for example os.Stderr to show progress and diagnostics to the user. Stderr is discarded if w is nil, which is the default. Other methods are not affected.
+func WithStderr(w io.Writer) LocalOption {
+	return func(e *LocalExecutor) {
+		e.stderr = w
+	}
+}
+
+// WithOutputHint preallocates n bytes for the captured output of every command. This avoids repeated reallocations for commands with large and predictable output. The preallocation is capped by WithMaxOutput and WithTailBuffer.
+func WithOutputHint(n int) LocalOption {
+	return func(e *LocalExecutor) {
+		e.outputHint = n
+	}
+}
+
+// WithTailBuffer limits the captured output to the last n bytes, which might begin in the middle of a line or UTF-8 sequence. The process is not affected and streamed output is not limited, so all output can be written to a log while only the tail is kept in memory. This option replaces WithMaxOutput.
+func WithTailBuffer(n int) LocalOption {
+	return func(e *LocalExecutor) {
+		e.tailOutput = n
+		e.maxOutput = 0
+	}
+}
+
+// WithFatalOutputPatterns makes commands fail with ErrFatalOutput if their output matches any of the given patterns, regardless of the exit code. ShouldRun and ShouldRunLine return this error as well.
+func WithFatalOutputPatterns(patterns ...*regexp.Regexp) LocalOption {
+	return func(e *LocalExecutor) {
+		e.fatalPatterns = append(e.fatalPatterns, patterns...)
+	}
+}
+
+// WithProcessGroup runs every command in a new process group. Signals sent to the command, including the kill on timeout or cancellation, are delivered to the whole group so that child processes do not survive as orphans. This option has no effect on Windows.
+func WithProcessGroup() LocalOption {
+	return func(e *LocalExecutor) {
+		e.processGroup = true
+	}
+}
+
+// WithGracefulShutdown sends sig to processes of cancelled commands instead of killing them immediately. The process is killed if it is still running after grace.
+func WithGracefulShutdown(sig os.Signal, grace time.Duration) LocalOption {
+	return func(e *LocalExecutor) {
+		e.shutdownSignal = sig
+		e.shutdownGrace = grace
+	}
+}
+
+// WithExitCodeParser uses parser to obtain the exit code from the output of commands that exited with code 0. This allows to handle wrapper scripts that report their result in the output instead of the exit code. The real exit code is kept if parser returns false.
+func WithExitCodeParser(parser ExitCodeParser) LocalOption {
+	return func(e *LocalExecutor) {
+		e.exitCodeParser = parser
+	}
+}
+
+// WithOutputEncoding transcodes the returned output of all commands from enc to UTF-8. A byte order mark at the beginning of the output takes precedence over enc, so UTF-8 and UTF-16 output with BOM is always decoded correctly. Streamed output is passed through unmodified.
+func WithOutputEncoding(enc encoding.Encoding) LocalOption {
+	return func(e *LocalExecutor) {
+		e.outputEncoding = enc
+	}
+}
+
+// WithOutputFilter applies filter to the output of all commands before it is returned or checked for fatal patterns. This allows to redact secrets centrally. Multiple filters are applied in the given order. Streamed output is passed through unmodified.
+func WithOutputFilter(filter func(string) string) LocalOption {
+	return func(e *LocalExecutor) {
+		e.outputFilters = append(e.outputFilters, filter)
+	}
+}
+
+// WithStripANSI removes ANSI escape sequences like colors from the output of all commands using StripANSI if enabled. Escape sequences are removed before output filters are applied. Streamed output is passed through unmodified.
+func WithStripANSI(enabled bool) LocalOption {
+	return func(e *LocalExecutor) {
+		e.stripANSI = enabled
+	}
+}
+
+// WithNormalizeNewlines converts "\r\n" and lone "\r" to "\n" in the output of all commands using NormalizeNewlines if enabled. Newlines are normalized after the output has been decoded and ANSI escape sequences have been removed, but before output filters are applied. Streamed output is passed through unmodified.
+func WithNormalizeNewlines(enabled bool) LocalOption {
+	return func(e *LocalExecutor) {
+		e.normalizeNL = enabled
+	}
+}
+
+// WithCredential runs all commands as the user uid with primary group gid and the given supplementary groups. The current process usually needs to run as root to do so, otherwise commands fail with ErrCredential. Credentials are not supported on Windows, where all commands fail with ErrCredential.
+func WithCredential(uid, gid uint32, groups ...uint32) LocalOption {
+	return func(e *LocalExecutor) {
+		e.credential = &credential{uid: uid, gid: gid, groups: append([]uint32{}, groups...)}
+	}
+}
+
+// WithNiceness runs all commands with the given nice value, where higher values denote a lower priority. The value is applied right after the process has been started, so the very first instructions still run with the inherited priority. Commands fail with ErrNiceness if the priority cannot be set, for example when lowering the nice value without sufficient privileges. Niceness is not supported on Windows, where all commands fail with ErrNiceness.
+func WithNiceness(n int) LocalOption {
+	return func(e *LocalExecutor) {
+		e.niceness = &n
+	}
+}
+
+// WithCmdConfigurator calls configure for every command after it has been fully set up and right before it is started. This allows to adjust settings of exec.Cmd that are not exposed by other options, like ExtraFiles or SysProcAttr. Multiple configurators are called in the given order. Changing Stdout or Stderr breaks output capturing.
+func WithCmdConfigurator(configure func(*exec.Cmd)) LocalOption {
+	return func(e *LocalExecutor) {
+		e.configurators = append(e.configurators, configure)
+	}
+}
+
+// WithExtraFiles passes open files like sockets or pipes to all commands in addition to stdin, stdout and stderr. The files are inherited in the given order starting at file descriptor 3, see ExtraFileFD. Multiple calls append further files. The files are not closed by the executor and must stay open until all commands have been started. Extra files are not supported on Windows, where commands fail with ErrRun.
+func WithExtraFiles(files ...*os.File) LocalOption {
+	return func(e *LocalExecutor) {
+		e.extraFiles = append(e.extraFiles, files...)
+	}
+}
+
+// ExtraFileFD returns the file descriptor number of the extra file at index i in the child process, which is 3 for the first file passed using WithExtraFiles.
+func ExtraFileFD(i int) int {
+	return 3 + i
+}
+
+// WithPTYSize sets the terminal size in columns and rows for commands run using RunPTY. It has no effect on platforms other than Linux, where RunPTY is not supported.
+func WithPTYSize(cols, rows uint16) LocalOption {
+	return func(e *LocalExecutor) {
+		e.ptyCols = cols
+		e.ptyRows = rows
+	}
+}
+
+// WithTrace writes every command line quoted by GetCommandLine to w right before the process is started, prefixed by "+ " and terminated by a line break like set -x in bash. Each line is written using a single call to w. Use nil to disable tracing.
+func WithTrace(w io.Writer) LocalOption {
+	return func(e *LocalExecutor) {
+		e.trace = w
+	}
 }
 
 // RunLine executes an escaped single string command line.
 func (e *LocalExecutor) RunLine(commandLine string) (string, int, errors.Error) {
-	return runLine(commandLine)
+	return e.RunLineContext(context.Background(), commandLine)
+}
+
+// RunLineContext executes an escaped single string command line and stops the process when ctx is cancelled.
+func (e *LocalExecutor) RunLineContext(ctx context.Context, commandLine string) (string, int, errors.Error) {
+	if e.envAssignments {
+		env, command, args, err := ParseWithEnvAssignments(commandLine)
+		if err != nil {
+			return "", 0, err
+		}
+		return e.With(WithEnv(env)).RunContext(ctx, command, args...)
+	}
+
+	command, args, err := Parse(commandLine)
+	if err != nil {
+		return "", 0, err
+	}
+
+	return e.RunContext(ctx, command, args...)
 }
 
 // Run executes a command line with separated arguments.
 func (e *LocalExecutor) Run(command string, args ...string) (string, int, errors.Error) {
-	return run(command, args...)
+	return e.RunContext(context.Background(), command, args...)
+}
+
+// RunContext executes a command line with separated arguments and stops the process when ctx is cancelled. The process is not started at all if ctx is already done.
+func (e *LocalExecutor) RunContext(ctx context.Context, command string, args ...string) (string, int, errors.Error) {
+	result := e.execute(ctx, nil, command, args...)
+	return result.Output, result.ExitCode, result.Err
+}
+
+// RunBytes executes a command line with separated arguments and returns the unmodified output bytes. The output is taken from the capture buffer, so WithOutputEncoding, WithStripANSI, WithNormalizeNewlines and WithOutputFilter are not applied, while WithMaxOutput and WithTailBuffer still limit the captured bytes. Fatal output patterns and the exit code parser are evaluated on the processed output like in Run.
+func (e *LocalExecutor) RunBytes(command string, args ...string) ([]byte, int, errors.Error) {
+	return e.RunBytesContext(context.Background(), command, args...)
+}
+
+// RunBytesContext executes a command line with separated arguments like RunBytes and stops the process when ctx is cancelled.
+func (e *LocalExecutor) RunBytesContext(ctx context.Context, command string, args ...string) ([]byte, int, errors.Error) {
+	output := e.newOutputBuffer(nil)
+	result := e.executeWith(ctx, nil, output, output, output, command, args...)
+	return []byte(output.String()), result.ExitCode, result.Err
+}
+
+// RunStream executes a command line with separated arguments and writes the output to w while it is produced. The complete output is returned as well.
+func (e *LocalExecutor) RunStream(w io.Writer, command string, args ...string) (string, int, errors.Error) {
+	result := e.execute(context.Background(), w, command, args...)
+	return result.Output, result.ExitCode, result.Err
+}
+
+// RunTimed executes a command line with separated arguments and additionally returns the elapsed wall-clock time from process start until exit.
+func (e *LocalExecutor) RunTimed(command string, args ...string) (string, int, time.Duration, errors.Error) {
+	result := e.execute(context.Background(), nil, command, args...)
+	return result.Output, result.ExitCode, result.Duration, result.Err
+}
+
+// Execute executes a command line with separated arguments like RunContext and returns all details of the execution.
+func (e *LocalExecutor) Execute(ctx context.Context, command string, args ...string) RunResult {
+	return e.execute(ctx, nil, command, args...)
+}
+
+// execute runs the command to completion and reports the result to OnRun.
+func (e *LocalExecutor) execute(ctx context.Context, stream io.Writer, command string, args ...string) RunResult {
+	output := e.newOutputBuffer(stream)
+	return e.executeWith(ctx, nil, output, output, output, command, args...)
+}
+
+// executeWith is like execute, but connects stdin, stdout and stderr of the process to the given streams. Only data forwarded to output by the writers is captured.
+func (e *LocalExecutor) executeWith(ctx context.Context, stdin io.Reader, output *outputBuffer, stdout, stderr io.Writer, command string, args ...string) RunResult {
+	return e.executeLaunch(ctx, func() (*RunHandle, errors.Error) {
+		return e.launch(stdin, output, stdout, stderr, command, args...)
+	}, command, args...)
+}
+
+// executeLaunch runs the process started by launch to completion and reports the result to OnRun. The process is launched as soon as an execution slot is available, see SetMaxConcurrent.
+func (e *LocalExecutor) executeLaunch(ctx context.Context, launch func() (*RunHandle, errors.Error), command string, args ...string) RunResult {
+	release, err := acquireSlot(ctx)
+	if err != nil {
+		result := RunResult{Err: err}
+		notifyRun(command, args, result)
+		return result
+	}
+
+	start := time.Now()
+	result := e.executeContext(ctx, launch)
+	result.Duration = time.Since(start)
+	release()
+	notifyRun(command, args, result)
+	return result
+}
+
+// executeContext launches the process and waits for it to finish. The process is not launched at all if ctx is already done.
+func (e *LocalExecutor) executeContext(ctx context.Context, launch func() (*RunHandle, errors.Error)) RunResult {
+	if err := ctx.Err(); err != nil {
+		return RunResult{Err: contextError(err)}
+	}
+	h, err := launch()
+	if err != nil {
+		return RunResult{Err: err}
+	}
+
+	waitCtx := ctx
+	isIdle := func() bool { return false }
+	if e.idleTimeout > 0 {
+		var stop func()
+		waitCtx, stop, isIdle = e.watchIdle(waitCtx, h)
+		defer stop()
+	}
+	if e.timeout > 0 {
+		var cancel context.CancelFunc
+		waitCtx, cancel = context.WithTimeout(waitCtx, e.timeout)
+		defer cancel()
+	}
+
+	var result RunResult
+	result.Output, result.ExitCode, result.Err = h.WaitContext(waitCtx)
+	if result.Err != nil && ctx.Err() == nil {
+		if isIdle() {
+			result.Err = ErrIdleTimeout.Args(e.idleTimeout).Make()
+		} else if waitCtx.Err() == context.DeadlineExceeded {
+			result.Err = ErrTimeout.Make().Cause(waitCtx.Err())
+		}
+	}
+	result.OutputBytes = h.output.Total()
+	result.Truncated = h.output.Truncated()
+	result.EscalatedToKill = h.escalated
+	result.Signal = h.signal
+	result.Output = e.filterOutput(e.decodeOutput(result.Output))
+	if result.Err == nil && result.ExitCode == 0 && e.exitCodeParser != nil {
+		if code, ok := e.exitCodeParser(result.Output); ok {
+			result.ExitCode = code
+		}
+	}
+	if result.Err == nil {
+		result.Err = e.checkFatalOutput(result.Output)
+	}
+	return result
+}
+
+// RunC executes a command like Run, but forces the C locale (LC_ALL=C and LANG=C) to obtain stable, locale-independent output.
+func (e *LocalExecutor) RunC(command string, args ...string) (string, int, errors.Error) {
+	return e.With(WithEnv(cLocale)).Run(command, args...)
+}
+
+// With returns a copy of the executor with additional options applied, leaving the original executor unchanged. This allows to configure defaults like working directory and environment once and override them for single calls, for example e.With(WithDir("/tmp")).Run("ls").
+//
+// Options of the copy take precedence over the defaults. Environment variables set by WithEnv are merged into the default environment and only overwrite variables with the same name, while WithCleanEnv replaces the default environment completely.
+func (e *LocalExecutor) With(options ...LocalOption) *LocalExecutor {
+	clone := *e
+	clone.env = make(map[string]string)
+	for key, value := range e.env {
+		clone.env[key] = value
+	}
+	clone.fatalPatterns = append([]*regexp.Regexp{}, e.fatalPatterns...)
+	clone.outputFilters = append([]func(string) string{}, e.outputFilters...)
+	clone.configurators = append([]func(*exec.Cmd){}, e.configurators...)
+	clone.extraFiles = append([]*os.File{}, e.extraFiles...)
+	for _, option := range options {
+		option(&clone)
+	}
+	return &clone
+}
+
+// filterOutput removes ANSI escape sequences and normalizes newlines if enabled and applies all output filters of the executor to output.
+func (e *LocalExecutor) filterOutput(output string) string {
+	if e.stripANSI {
+		output = StripANSI(output)
+	}
+	if e.normalizeNL {
+		output = NormalizeNewlines(output)
+	}
+	for _, filter := range e.outputFilters {
+		output = filter(output)
+	}
+	return output
+}
+
+// checkFatalOutput returns ErrFatalOutput for the first fatal pattern matching output.
+func (e *LocalExecutor) checkFatalOutput(output string) errors.Error {
+	for _, pattern := range e.fatalPatterns {
+		if pattern.MatchString(output) {
+			return ErrFatalOutput.Args(pattern.String()).Make()
+		}
+	}
+	return nil
+}
+
+// environment returns the environment for child processes or nil to inherit the environment of the current process.
+func (e *LocalExecutor) environment() []string {
+	if e.cleanEnv {
+		return e.cleanEnvironment()
+	}
+	if len(e.env) == 0 {
+		return nil
+	}
+
+	keys := make([]string, 0, len(e.env))
+	for key := range e.env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	env := make([]string, 0)
+	for _, item := range os.Environ() {
+		if _, ok := e.env[strings.SplitN(item, "=", 2)[0]]; !ok {
+			env = append(env, item)
+		}
+	}
+	for _, key := range keys {
+		env = append(env, key+"="+e.env[key])
+	}
+	return env
+}
+
+// cleanEnvironment returns only the configured variables and the default PATH if not configured.
+func (e *LocalExecutor) cleanEnvironment() []string {
+	keys := make([]string, 0, len(e.env))
+	for key := range e.env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	env := make([]string, 0, len(keys)+1)
+	if _, ok := e.env["PATH"]; !ok {
+		env = append(env, "PATH="+cleanPath)
+	}
+	for _, key := range keys {
+		env = append(env, key+"="+e.env[key])
+	}
+	return env
+}
+
+// lookPath resolves command using the PATH of the clean environment. Commands containing a path separator are returned unmodified.
+func (e *LocalExecutor) lookPath(command string) (string, error) {
+	if hasPathSeparator(command) {
+		return command, nil
+	}
+
+	pathList, ok := e.env["PATH"]
+	if !ok {
+		pathList = cleanPath
+	}
+	for _, dir := range filepath.SplitList(pathList) {
+		if dir == "" {
+			continue
+		}
+		if path, err := exec.LookPath(filepath.Join(dir, command)); err == nil {
+			return path, nil
+		}
+	}
+	return "", &exec.Error{Name: command, Err: exec.ErrNotFound}
 }
 
 // NewLocalExecutor returns an executor for the local shell.
-func NewLocalExecutor() *LocalExecutor {
-	return &LocalExecutor{}
+func NewLocalExecutor(options ...LocalOption) *LocalExecutor {
+	e := &LocalExecutor{}
+	for _, option := range options {
+		option(e)
+	}
+	return e
 }
 
-// MockExecutor offers functionality to mock and debug executed commands.
+// MockExecutor offers functionality to mock and debug executed commands. It is safe for concurrent use, but callbacks may be called concurrently and need to synchronize access to shared state on their own.
 type MockExecutor struct {
+	// Delay is waited before every call to simulate long running commands.
+	Delay       time.Duration
 	RunCallback func(command string, args ...string) (string, int, errors.Error)
+	// RunBytesCallback is used instead of RunCallback if set. This allows to simulate commands with binary output.
+	RunBytesCallback func(command string, args ...string) ([]byte, int, errors.Error)
+	// WhichCallback answers calls to Which. Which returns ErrCommandNotFound for all commands if not set.
+	WhichCallback func(command string) (string, errors.Error)
+	// DefaultResult is returned for all commands that are neither matched by ReturnSequence nor answered by a callback. The zero value denotes a successful command without output.
+	DefaultResult MockResult
+	// Strict makes commands that are neither matched by ReturnSequence nor answered by a callback fail with ErrUnexpectedCommand instead of returning DefaultResult.
+	Strict bool
+
+	mutex     sync.Mutex
+	sequences map[string][]MockResult
+	calls     []CommandSpec
+}
+
+// MockResult denotes the predetermined result of a mocked command.
+type MockResult struct {
+	Output   string
+	ExitCode int
+	Err      errors.Error
+}
+
+// ReturnSequence makes successive calls of command return the given results in order. Callbacks are used again as soon as all results have been returned. Commands are matched by their name only, regardless of the arguments.
+func (e *MockExecutor) ReturnSequence(command string, results ...MockResult) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	if e.sequences == nil {
+		e.sequences = make(map[string][]MockResult)
+	}
+	e.sequences[command] = append(e.sequences[command], results...)
+}
+
+// Calls returns all commands that have been executed in order. Commands cancelled before execution and command lines that could not be parsed are not included.
+func (e *MockExecutor) Calls() []CommandSpec {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	return append([]CommandSpec{}, e.calls...)
+}
+
+// nextResult records the call and returns and removes the next result of the sequence for command.
+func (e *MockExecutor) nextResult(command string, args []string) (MockResult, bool) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.calls = append(e.calls, CommandSpec{Command: command, Args: append([]string(nil), args...)})
+	sequence := e.sequences[command]
+	if len(sequence) == 0 {
+		return MockResult{}, false
+	}
+	e.sequences[command] = sequence[1:]
+	return sequence[0], true
 }
 
 // RunLine parses the command and calls RunCallback.
@@ -70,27 +646,96 @@ func (e *MockExecutor) RunLine(commandLine string) (string, int, errors.Error) {
 		return "", 0, err
 	}
 
-	return e.RunCallback(command, args...)
+	return e.Run(command, args...)
 }
 
 // Run calls runCallback.
 func (e *MockExecutor) Run(command string, args ...string) (string, int, errors.Error) {
-	return e.RunCallback(command, args...)
+	time.Sleep(e.Delay)
+	return e.call(command, args...)
+}
+
+// RunContext calls RunCallback after Delay, but returns ErrCanceled or ErrTimeout if ctx is done before. The callback is never called if ctx is already done, even without Delay.
+func (e *MockExecutor) RunContext(ctx context.Context, command string, args ...string) (string, int, errors.Error) {
+	if err := ctx.Err(); err != nil {
+		return "", 0, contextError(err)
+	}
+	timer := time.NewTimer(e.Delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return e.call(command, args...)
+	case <-ctx.Done():
+		return "", 0, contextError(ctx.Err())
+	}
+}
+
+func (e *MockExecutor) call(command string, args ...string) (string, int, errors.Error) {
+	if result, ok := e.nextResult(command, args); ok {
+		return result.Output, result.ExitCode, result.Err
+	}
+	if e.RunBytesCallback != nil {
+		output, code, err := e.RunBytesCallback(command, args...)
+		return string(output), code, err
+	}
+	if e.RunCallback != nil {
+		return e.RunCallback(command, args...)
+	}
+	result := e.unmatchedResult(command, args)
+	return result.Output, result.ExitCode, result.Err
 }
 
-// NewMockExecutor returns an executor for the local shell.
+// unmatchedResult returns the result for commands without sequence and callback.
+func (e *MockExecutor) unmatchedResult(command string, args []string) MockResult {
+	if e.Strict {
+		return MockResult{Err: ErrUnexpectedCommand.Args(GetCommandLine(command, args...)).Make()}
+	}
+	return e.DefaultResult
+}
+
+// RunBytes returns the next result of a sequence defined by ReturnSequence, or calls RunBytesCallback or RunCallback if not set. DefaultResult is returned if neither is set.
+func (e *MockExecutor) RunBytes(command string, args ...string) ([]byte, int, errors.Error) {
+	time.Sleep(e.Delay)
+	if result, ok := e.nextResult(command, args); ok {
+		return []byte(result.Output), result.ExitCode, result.Err
+	}
+	if e.RunBytesCallback != nil {
+		return e.RunBytesCallback(command, args...)
+	}
+	if e.RunCallback != nil {
+		output, code, err := e.RunCallback(command, args...)
+		return []byte(output), code, err
+	}
+	result := e.unmatchedResult(command, args)
+	return []byte(result.Output), result.ExitCode, result.Err
+}
+
+// RunStream calls RunCallback and writes the returned output to w before returning. This allows to simulate commands that produced partial output before failing.
+func (e *MockExecutor) RunStream(w io.Writer, command string, args ...string) (string, int, errors.Error) {
+	output, code, err := e.Run(command, args...)
+	io.WriteString(w, output)
+	return output, code, err
+}
+
+// NewMockExecutor returns an executor for the local shell. Use a nil runCallback to answer all commands not matched by ReturnSequence with DefaultResult.
 func NewMockExecutor(runCallback func(command string, args ...string) (string, int, errors.Error)) *MockExecutor {
-	return &MockExecutor{runCallback}
+	return &MockExecutor{RunCallback: runCallback}
+}
+
+// NewMockBytesExecutor returns a mock executor that obtains binary output from runBytesCallback.
+func NewMockBytesExecutor(runBytesCallback func(command string, args ...string) ([]byte, int, errors.Error)) *MockExecutor {
+	return &MockExecutor{RunBytesCallback: runBytesCallback}
 }
 
-// ShouldRunLine executes the given command using RunLine but returns an error for non-zero return codes.
+// ShouldRunLine executes the given command using RunLine but returns an error for non-zero return codes. The error is of type *ReturnCodeError and includes the output.
 func ShouldRunLine(commandLine string) (string, errors.Error) {
 	result, code, err := RunLine(commandLine)
 	if err != nil {
-		return "", err
+		return result, err
 	}
 	if code != 0 {
-		return result, ErrReturnCode.Args(code).Make()
+		return result, newReturnCodeError(code, result)
 	}
 	return result, nil
 }
@@ -100,63 +745,237 @@ func RunLine(commandLine string) (string, int, errors.Error) {
 	return DefaultExecutor.RunLine(commandLine)
 }
 
-func runLine(commandLine string) (string, int, errors.Error) {
+// RunLineCanonical parses the given command line and runs it like RunLine, but additionally returns the canonical command line that has been executed. The canonical command line is reconstructed from the parsed tokens using GetCommandLine, so differently quoted inputs for the same command yield the same representation, which is suitable for logging and as key for caching or metrics.
+func RunLineCanonical(commandLine string) (string, int, string, errors.Error) {
+	command, args, err := Parse(commandLine)
+	if err != nil {
+		return "", 0, "", err
+	}
+
+	canonical := GetCommandLine(command, args...)
+	output, code, err := DefaultExecutor.Run(command, args...)
+	return output, code, canonical, err
+}
+
+// CanonicalCommandLine returns the normalized representation of a command line as executed by RunLineCanonical without running it.
+func CanonicalCommandLine(commandLine string) (string, errors.Error) {
 	command, args, err := Parse(commandLine)
+	if err != nil {
+		return "", err
+	}
+	return GetCommandLine(command, args...), nil
+}
+
+// Succeeds executes the given command using Run and returns true only if it could be executed and returned exit code 0. The output is discarded.
+func Succeeds(command string, args ...string) bool {
+	_, code, err := Run(command, args...)
+	return err == nil && code == 0
+}
+
+// SucceedsLine executes the given command line using RunLine and returns true only if it could be executed and returned exit code 0. The output is discarded.
+func SucceedsLine(commandLine string) bool {
+	_, code, err := RunLine(commandLine)
+	return err == nil && code == 0
+}
+
+// RunMixed appends the tokens of the command line tail to the already separated command and arguments in base and runs the result using the DefaultExecutor. This allows to extend a command with user input without quoting base again. Parse errors of tail are returned unmodified.
+func RunMixed(base []string, tail string) (string, int, errors.Error) {
+	parts, err := split(tail, DefaultParseConfig())
 	if err != nil {
 		return "", 0, err
 	}
 
-	return run(command, args...)
+	parts = append(append([]string{}, base...), parts...)
+	if len(parts) == 0 {
+		return "", 0, newParseError(ParseErrorEmptyInput, "Unexpected end of command line")
+	}
+	return DefaultExecutor.Run(parts[0], parts[1:]...)
 }
 
-// ShouldRun executes the given command using Run but returns an error for non-zero return codes.
+// RunArgs executes a command with the given argument slice using the DefaultExecutor. It is equivalent to Run(command, args...).
+func RunArgs(command string, args []string) (string, int, errors.Error) {
+	return DefaultExecutor.Run(command, args...)
+}
+
+// RunLineFrom executes an already split command line using the DefaultExecutor, where parts[0] denotes the command and all remaining parts its arguments. The parts are passed as they are without parsing or quoting. An empty slice results in a parse error like an empty command line for RunLine.
+func RunLineFrom(parts []string) (string, int, errors.Error) {
+	if len(parts) == 0 {
+		return "", 0, newParseError(ParseErrorEmptyInput, "Unexpected end of command line")
+	}
+	return DefaultExecutor.Run(parts[0], parts[1:]...)
+}
+
+// ShouldRun executes the given command using Run but returns an error for non-zero return codes. The error is of type *ReturnCodeError and includes the output.
 func ShouldRun(command string, args ...string) (string, errors.Error) {
 	result, code, err := Run(command, args...)
 	if err != nil {
-		return "", err
+		return result, err
 	}
 	if code != 0 {
-		return result, ErrReturnCode.Args(code).Make()
+		return result, newReturnCodeError(code, result)
 	}
 	return result, nil
 }
 
+// RunContext executes a command with given arguments using the DefaultExecutor and stops it when ctx is cancelled. Executors that do not implement ContextRunner are only prevented from starting if ctx is already cancelled.
+func RunContext(ctx context.Context, command string, args ...string) (string, int, errors.Error) {
+	return runContext(DefaultExecutor, ctx, command, args...)
+}
+
+func runContext(e Executor, ctx context.Context, command string, args ...string) (string, int, errors.Error) {
+	if c, ok := e.(ContextRunner); ok {
+		return c.RunContext(ctx, command, args...)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return "", 0, contextError(err)
+	}
+	return e.Run(command, args...)
+}
+
+// runParsed parses the command line and runs it using Run of e. It implements RunLine for executors that need the separated command and arguments, so the command line is never interpreted by RunLine of an inner executor.
+func runParsed(e Executor, commandLine string) (string, int, errors.Error) {
+	command, args, err := Parse(commandLine)
+	if err != nil {
+		return "", 0, err
+	}
+
+	return e.Run(command, args...)
+}
+
+// contextError returns ErrTimeout if err denotes an exceeded context deadline and ErrCanceled otherwise. The returned error is caused by err.
+func contextError(err error) errors.Error {
+	if err == context.DeadlineExceeded {
+		return ErrTimeout.Make().Cause(err)
+	}
+	return ErrCanceled.Make().Cause(err)
+}
+
+// RunTimed executes a command with given arguments using the DefaultExecutor and additionally returns the elapsed wall-clock time.
+func RunTimed(command string, args ...string) (string, int, time.Duration, errors.Error) {
+	if e, ok := DefaultExecutor.(*LocalExecutor); ok {
+		return e.RunTimed(command, args...)
+	}
+
+	start := time.Now()
+	output, code, err := DefaultExecutor.Run(command, args...)
+	return output, code, time.Since(start), err
+}
+
+// RunBytes executes a command with given arguments using the DefaultExecutor and returns the unmodified output bytes.
+func RunBytes(command string, args ...string) ([]byte, int, errors.Error) {
+	return DefaultExecutor.RunBytes(command, args...)
+}
+
+// RunC executes a command with given arguments using the DefaultExecutor and forces the C locale for stable output. The locale is applied through the environment of LocalExecutor and ShellExecutor. Other executors run the command unchanged, so the C locale is not guaranteed for them.
+func RunC(command string, args ...string) (string, int, errors.Error) {
+	switch e := DefaultExecutor.(type) {
+	case *LocalExecutor:
+		return e.RunC(command, args...)
+	case *ShellExecutor:
+		return e.local.RunC(command, args...)
+	}
+	return DefaultExecutor.Run(command, args...)
+}
+
 // Run executes a command with given arguments using the DefaultExecutor.
 func Run(command string, args ...string) (string, int, errors.Error) {
 	return DefaultExecutor.Run(command, args...)
 }
 
-func run(command string, args ...string) (string, int, errors.Error) {
-	cmd := exec.Command(command, args...)
-	output, err := cmd.CombinedOutput()
+// exitStatus returns the exit code and the terminating signal, if any, for the result of cmd.Wait.
+func exitStatus(err error) (int, os.Signal, errors.Error) {
 	if err != nil {
 		switch e := err.(type) {
 		case *exec.ExitError:
 			switch s := e.Sys().(type) {
 			case syscall.WaitStatus:
-				return string(output), s.ExitStatus(), nil
+				if s.Signaled() {
+					// follow the convention of shells to report termination by signal N as 128+N
+					return 128 + int(s.Signal()), s.Signal(), nil
+				}
+				return s.ExitStatus(), nil, nil
 			}
 		}
-		return string(output), 0, ErrRun.Make().Cause(err)
+		return 0, nil, ErrRun.Make().Cause(err)
 	}
 
-	return string(output), 0, nil
+	return 0, nil, nil
 }
 
 const (
 	parseDefault = iota
 	parseSingleQuote
 	parseDoubleQuote
+	parseANSICQuote
 )
 
-// Parse returns the command and arguments from a command line.
+// ParseConfig defines the special runes used by the command line parser.
+type ParseConfig struct {
+	// SingleQuote denotes the rune that encloses literal strings without escape sequences.
+	SingleQuote rune
+	// DoubleQuote denotes the rune that encloses strings that may contain escaped quotes.
+	DoubleQuote rune
+	// Escape denotes the rune that escapes the following rune.
+	Escape rune
+	// DisableEscape treats the escape rune literally outside of ANSI-C quotes. This allows to parse Windows paths like C:\Users without doubling backslashes.
+	DisableEscape bool
+	// MaxTokens denotes the maximum number of tokens (command and arguments) of a command line. Zero means unlimited.
+	MaxTokens int
+	// MaxLength denotes the maximum length of a command line in bytes. Zero means unlimited.
+	MaxLength int
+}
+
+// DefaultParseConfig returns the configuration used by Parse with POSIX shell quotes and backslash escapes.
+func DefaultParseConfig() ParseConfig {
+	return ParseConfig{SingleQuote: sqt, DoubleQuote: dqt, Escape: esc}
+}
+
+// validate ensures that all special runes are distinct and printable.
+func (cfg ParseConfig) validate() errors.Error {
+	if cfg.MaxTokens < 0 || cfg.MaxLength < 0 {
+		return newParseError(ParseErrorInvalidConfig, "Negative limits in parse config")
+	}
+
+	runes := []rune{cfg.SingleQuote, cfg.DoubleQuote, cfg.Escape}
+	for i, r := range runes {
+		if r == eol || unicode.IsSpace(r) || r == dlr {
+			return newParseError(ParseErrorInvalidConfig, "Invalid special rune %q in parse config", r)
+		}
+		for _, other := range runes[i+1:] {
+			if r == other {
+				return newParseError(ParseErrorInvalidConfig, "Special rune %q is used more than once in parse config", r)
+			}
+		}
+	}
+	return nil
+}
+
+// Parse returns the command and arguments from a command line. An escape rune directly followed by a line break outside of single quotes is removed entirely to continue the command line on the next line like in POSIX shells.
 func Parse(commandLine string) (string, []string, errors.Error) {
-	parts, err := split(commandLine)
+	return ParseWith(commandLine, DefaultParseConfig())
+}
+
+// ParseLimited returns the command and arguments from a command line like Parse, but fails with ErrParse if the command line is longer than maxLen bytes or contains more than maxTokens tokens (command and arguments). This allows to process untrusted input without risking resource exhaustion.
+func ParseLimited(commandLine string, maxTokens int, maxLen int) (string, []string, errors.Error) {
+	cfg := DefaultParseConfig()
+	cfg.MaxTokens = maxTokens
+	cfg.MaxLength = maxLen
+	return ParseWith(commandLine, cfg)
+}
+
+// ParseWith returns the command and arguments from a command line using custom quote and escape runes.
+func ParseWith(commandLine string, cfg ParseConfig) (string, []string, errors.Error) {
+	if err := cfg.validate(); err != nil {
+		return "", nil, err
+	}
+
+	parts, err := split(commandLine, cfg)
 	if err != nil {
 		return "", nil, err
 	}
 	if len(parts) == 0 {
-		return "", nil, ErrParse.Make().Msg("Unexpected end of command line")
+		return "", nil, newParseError(ParseErrorEmptyInput, "Unexpected end of command line")
 	} else if len(parts) == 1 {
 		return parts[0], nil, nil
 	} else {
@@ -164,27 +983,117 @@ func Parse(commandLine string) (string, []string, errors.Error) {
 	}
 }
 
-func split(str string) ([]string, errors.Error) {
+// ParseWithEnvAssignments returns the command and arguments from a command line like Parse, but removes leading variable assignments like FOO=bar and returns them as environment map. Only words starting with an unquoted variable name followed by an equals sign are assignments, while the value may be quoted as in FOO="a b". Assignments after the command are regular arguments.
+func ParseWithEnvAssignments(commandLine string) (map[string]string, string, []string, errors.Error) {
+	tokens, err := tokenize(commandLine, DefaultParseConfig(), nil)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	env := make(map[string]string)
+	for len(tokens) > 0 && tokens[0].assignment {
+		parts := strings.SplitN(tokens[0].value, "=", 2)
+		env[parts[0]] = parts[1]
+		tokens = tokens[1:]
+	}
+	if len(tokens) == 0 {
+		if len(env) > 0 {
+			return nil, "", nil, newParseError(ParseErrorSyntax, "Missing command after environment assignments")
+		}
+		return nil, "", nil, newParseError(ParseErrorEmptyInput, "Unexpected end of command line")
+	}
+
+	var args []string
+	for _, t := range tokens[1:] {
+		args = append(args, t.value)
+	}
+	return env, tokens[0].value, args, nil
+}
+
+// Fields returns all tokens of a command line, including the command itself, without executing it. In contrast to Parse, an empty command line results in an empty slice instead of an error.
+func Fields(commandLine string) ([]string, errors.Error) {
+	return split(commandLine, DefaultParseConfig())
+}
+
+func split(str string, cfg ParseConfig) ([]string, errors.Error) {
+	tokens, err := tokenize(str, cfg, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	parts := make([]string, len(tokens))
+	for i, t := range tokens {
+		parts[i] = t.value
+	}
+	return parts, nil
+}
+
+// token denotes a single part of a command line, either a decoded word or an unquoted operator.
+type token struct {
+	value    string
+	operator bool
+	// assignment is set for words starting with an unquoted variable name followed by an equals sign
+	assignment bool
+	// start and end denote the rune range of the word in the command line
+	start, end int
+	// styles denotes the quoting styles used in the word in order of appearance
+	styles []QuoteStyle
+}
+
+// tokenize splits a command line into words and the given operators. Operators are only recognized outside of quotes and escape sequences and do not need to be separated by spaces.
+func tokenize(str string, cfg ParseConfig, operators []string) ([]token, errors.Error) {
+	if cfg.MaxLength > 0 && len(str) > cfg.MaxLength {
+		return nil, newParseError(ParseErrorLimitExceeded, "Command line exceeds maximum length of %d bytes", cfg.MaxLength)
+	}
+
 	// array that holds all seen string parts
-	parts := make([]string, 0)
+	parts := make([]token, 0)
 
 	// parser state to handle quotes and escape sequences
 	state := parseDefault
 	escape := false
 	// the builder assembles the currently processed string part
 	var sb strings.Builder
-	partStart := 0
+	// inPart is set as soon as the first rune of a part has been seen, quotes and escapes included
+	inPart := false
+	// plain is cleared as soon as quotes or escapes have been seen in the current part
+	plain := true
+	assignment := false
+	start := 0
+	var styles []QuoteStyle
+	addStyle := func(style QuoteStyle) {
+		for _, s := range styles {
+			if s == style {
+				return
+			}
+		}
+		styles = append(styles, style)
+	}
+	// endPart finishes the current part, which ends right before the rune at index end
+	endPart := func(end int) {
+		parts = append(parts, token{value: sb.String(), assignment: assignment, start: start, end: end, styles: styles})
+		sb.Reset()
+		inPart = false
+		plain = true
+		assignment = false
+		styles = nil
+	}
 
 	// append EOL (end of line) to command line string for easier processing
 	runes := []rune(str + string(eol))
-	for i, r := range runes {
+	for i := 0; i < len(runes); i++ {
+		if cfg.MaxTokens > 0 && len(parts) > cfg.MaxTokens {
+			return nil, newParseError(ParseErrorLimitExceeded, "Command line exceeds maximum number of %d tokens", cfg.MaxTokens)
+		}
+
+		r := runes[i]
 		if r == eol {
 			if i < (len(runes) - 1) {
 				// EOL is ONLY allowed as last char
-				return nil, ErrParse.Make().Msg("Invalid 0 char in command line")
+				return nil, newParseError(ParseErrorInvalidNullChar, "Invalid 0 char in command line")
 			} else if state != parseDefault || escape {
 				// last char (EOL) reached but still in quote?
-				return nil, ErrParse.Make().Msg("Unexpected end of command line")
+				return nil, newParseError(unterminatedKind(state), "Unexpected end of command line")
 			}
 		}
 
@@ -197,28 +1106,64 @@ func split(str string) ([]string, errors.Error) {
 				// space runes in default context (not quoted) end the current part
 				if unicode.IsSpace(r) || r == eol {
 					// ignore multiple consecutive spaces
-					if partStart < (i - 1) {
+					if inPart {
 						// append to parts and begin new one
-						parts = append(parts, sb.String())
-						sb.Reset()
+						endPart(i)
+					}
+					continue
+				}
+
+				// operators starting with a digit (like 2>) are only recognized at the beginning of a part
+				if op := matchOperator(runes[i:], operators); len(op) > 0 && !(inPart && unicode.IsDigit([]rune(op)[0])) {
+					// operators end the current part like spaces
+					if inPart {
+						endPart(i)
 					}
-					// keep track of part begin to detect large spaces inbetween arguments
-					partStart = i
-				} else if r == sqt {
+					parts = append(parts, token{value: op, operator: true, start: i, end: i + len([]rune(op))})
+					i += len([]rune(op)) - 1
+					continue
+				}
+
+				if n := continuationLength(runes[i:], cfg); n > 0 {
+					// line continuations are removed entirely and do not start a new part
+					i += n - 1
+					continue
+				}
+
+				if !inPart {
+					start = i
+				}
+				inPart = true
+				if r == dlr && runes[i+1] == cfg.SingleQuote {
+					// ANSI-C quoting $'...' -> skip the opening quote
+					state = parseANSICQuote
+					plain = false
+					addStyle(StyleANSIC)
+					i++
+				} else if r == cfg.SingleQuote {
 					// do not end current part -> quotes can be combined
 					state = parseSingleQuote
-				} else if r == dqt {
+					plain = false
+					addStyle(StyleSingle)
+				} else if r == cfg.DoubleQuote {
 					// do not end current part -> quotes can be combined
 					state = parseDoubleQuote
-				} else if r == esc {
+					plain = false
+					addStyle(StyleDouble)
+				} else if r == cfg.Escape && !cfg.DisableEscape {
 					escape = true
+					plain = false
+					addStyle(StyleRaw)
 				} else {
+					if r == '=' && plain && !assignment && isEnvName(sb.String()) {
+						assignment = true
+					}
 					sb.WriteRune(r)
 				}
 			}
 
 		case parseSingleQuote:
-			if r == sqt {
+			if r == cfg.SingleQuote {
 				state = parseDefault
 			} else {
 				sb.WriteRune(r)
@@ -227,14 +1172,30 @@ func split(str string) ([]string, errors.Error) {
 		case parseDoubleQuote:
 			if escape {
 				escape = false
-				if r != esc && r != dqt {
-					sb.WriteRune(esc)
+				if r != cfg.Escape && r != cfg.DoubleQuote {
+					sb.WriteRune(cfg.Escape)
 				}
 				sb.WriteRune(r)
 			} else {
-				if r == dqt {
+				if r == cfg.DoubleQuote {
+					state = parseDefault
+				} else if n := continuationLength(runes[i:], cfg); n > 0 {
+					i += n - 1
+				} else if r == cfg.Escape && !cfg.DisableEscape {
+					escape = true
+				} else {
+					sb.WriteRune(r)
+				}
+			}
+
+		case parseANSICQuote:
+			if escape {
+				escape = false
+				i += decodeANSICEscape(&sb, runes, i, cfg)
+			} else {
+				if r == cfg.SingleQuote {
 					state = parseDefault
-				} else if r == esc {
+				} else if r == cfg.Escape {
 					escape = true
 				} else {
 					sb.WriteRune(r)
@@ -243,29 +1204,257 @@ func split(str string) ([]string, errors.Error) {
 		}
 	}
 
+	if cfg.MaxTokens > 0 && len(parts) > cfg.MaxTokens {
+		return nil, newParseError(ParseErrorLimitExceeded, "Command line exceeds maximum number of %d tokens", cfg.MaxTokens)
+	}
 	return parts, nil
 }
 
-// GetCommandLine is the inverse function of Parse. It assembles a single command line that is equivalent to the given command and arguments by escaping and quoting.
+// isEnvName returns true if name is a valid name of an environment variable in POSIX shells.
+func isEnvName(name string) bool {
+	if len(name) == 0 {
+		return false
+	}
+	for i, r := range name {
+		if !(r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (i > 0 && r >= '0' && r <= '9')) {
+			return false
+		}
+	}
+	return true
+}
+
+// continuationLength returns the number of runes of a line continuation (escape rune followed by a line break) at the beginning of runes or zero if runes does not start with a line continuation.
+func continuationLength(runes []rune, cfg ParseConfig) int {
+	if cfg.DisableEscape || len(runes) < 2 || runes[0] != cfg.Escape {
+		return 0
+	}
+	if runes[1] == '\n' {
+		return 2
+	}
+	if runes[1] == '\r' && len(runes) > 2 && runes[2] == '\n' {
+		return 3
+	}
+	return 0
+}
+
+// matchOperator returns the longest operator that runes starts with or an empty string.
+func matchOperator(runes []rune, operators []string) string {
+	match := ""
+	for _, op := range operators {
+		opRunes := []rune(op)
+		if len(opRunes) > len(runes) || len(op) <= len(match) {
+			continue
+		}
+		if string(runes[:len(opRunes)]) == op {
+			match = op
+		}
+	}
+	return match
+}
+
+var ansiCEscapes = map[rune]rune{
+	'n': '\n',
+	't': '\t',
+	'r': '\r',
+	'a': '\a',
+	'b': '\b',
+	'f': '\f',
+	'v': '\v',
+	'e': '\x1b',
+	'E': '\x1b',
+	'?': '?',
+}
+
+// decodeANSICEscape writes the character denoted by the escape sequence starting at runes[i] (right after the backslash) to sb and returns the number of additionally consumed runes. Supported are all sequences of bash: \n, \t, \r, \a, \b, \f, \v, \e, \E, \?, escaped quotes, \NNN (octal byte), \xHH (hex byte), \uHHHH and \UHHHHHHHH (Unicode code points) and \cX (control character).
+func decodeANSICEscape(sb *strings.Builder, runes []rune, i int, cfg ParseConfig) int {
+	r := runes[i]
+	if r == cfg.Escape || r == cfg.SingleQuote || r == cfg.DoubleQuote {
+		sb.WriteRune(r)
+		return 0
+	}
+	if decoded, ok := ansiCEscapes[r]; ok {
+		sb.WriteRune(decoded)
+		return 0
+	}
+
+	switch {
+	case r >= '0' && r <= '7':
+		// \NNN denotes a single byte, the first digit is already consumed
+		value, n := parseDigits(runes[i:], 3, 8)
+		sb.WriteByte(byte(value))
+		return n - 1
+
+	case r == 'x' || r == 'u' || r == 'U':
+		maxDigits := map[rune]int{'x': 2, 'u': 4, 'U': 8}[r]
+		value, n := parseDigits(runes[i+1:], maxDigits, 16)
+		if n > 0 {
+			if r == 'x' {
+				// \xHH denotes a single byte
+				sb.WriteByte(byte(value))
+			} else {
+				sb.WriteRune(rune(value))
+			}
+			return n
+		}
+
+	case r == 'c' && runes[i+1] != eol:
+		// \cX denotes the control character of X, \c? denotes DEL
+		x := runes[i+1]
+		if x == '?' {
+			sb.WriteRune('\x7f')
+		} else {
+			sb.WriteRune(unicode.ToUpper(x) & 0x1f)
+		}
+		return 1
+	}
+
+	// unknown escape sequences are kept as they are
+	sb.WriteRune(cfg.Escape)
+	sb.WriteRune(r)
+	return 0
+}
+
+// parseDigits reads up to maxDigits digits of the given base and returns their value and the number of digits read.
+func parseDigits(runes []rune, maxDigits int, base int) (int, int) {
+	value := 0
+	n := 0
+	for n < maxDigits && n < len(runes) {
+		digit := strings.IndexRune("0123456789abcdef"[:base], unicode.ToLower(runes[n]))
+		if digit < 0 {
+			break
+		}
+		value = value*base + digit
+		n++
+	}
+	return value, n
+}
+
+// GetCommandLine is the inverse function of Parse. It assembles a single command line that is equivalent to the given command and arguments by escaping and quoting. Parse always returns the original command and arguments for valid UTF-8 input, invalid byte sequences are replaced by the Unicode replacement character U+FFFD.
 func GetCommandLine(command string, args ...string) string {
+	return strings.Join(GetCommandLineParts(command, args...), " ")
+}
+
+// GetCommandLineParts returns the individually quoted command and arguments that are joined by GetCommandLine. This allows to inspect the quoting decision for every single argument.
+func GetCommandLineParts(command string, args ...string) []string {
+	parts := make([]string, 0, len(args)+1)
+	parts = append(parts, Quote(command))
+	for _, arg := range args {
+		parts = append(parts, Quote(arg))
+	}
+	return parts
+}
+
+// QuoteANSIC returns a representation of the given string in ANSI-C quoting ($'...') that encodes control characters as escape sequences.
+func QuoteANSIC(str string) string {
 	var sb strings.Builder
-	sb.WriteString(Quote(command))
+	sb.WriteRune(dlr)
+	sb.WriteRune(sqt)
+	for _, r := range []rune(str) {
+		switch r {
+		case '\n':
+			sb.WriteString(`\n`)
+		case '\t':
+			sb.WriteString(`\t`)
+		case '\r':
+			sb.WriteString(`\r`)
+		case esc, sqt:
+			sb.WriteRune(esc)
+			sb.WriteRune(r)
+		default:
+			if r < 0x80 && unicode.IsControl(r) {
+				fmt.Fprintf(&sb, `\x%02x`, r)
+			} else if unicode.IsControl(r) {
+				fmt.Fprintf(&sb, `\u%04x`, r)
+			} else {
+				sb.WriteRune(r)
+			}
+		}
+	}
+	sb.WriteRune(sqt)
+	return sb.String()
+}
+
+// QuoteStyle denotes a quoting style for command line arguments.
+type QuoteStyle int
+
+const (
+	// StyleAuto selects the shortest representation like Quote.
+	StyleAuto QuoteStyle = iota
+	// StyleRaw escapes special characters using backslashes like QuoteRaw.
+	StyleRaw
+	// StyleSingle encloses arguments in single quotes like QuoteSingle.
+	StyleSingle
+	// StyleDouble encloses arguments in double quotes like QuoteDouble.
+	StyleDouble
+	// StyleANSIC encloses arguments in ANSI-C quotes like QuoteANSIC.
+	StyleANSIC
+	// StyleReadable prefers double quotes over escaping like QuoteReadable.
+	StyleReadable
+)
+
+// Quote returns a safe representation of the given string using the quoting style.
+func (s QuoteStyle) Quote(str string) string {
+	switch s {
+	case StyleRaw:
+		return QuoteRaw(str)
+	case StyleSingle:
+		return QuoteSingle(str)
+	case StyleDouble:
+		return QuoteDouble(str)
+	case StyleANSIC:
+		return QuoteANSIC(str)
+	case StyleReadable:
+		return QuoteReadable(str)
+	default:
+		return Quote(str)
+	}
+}
+
+// QuoteReadable returns a representation of the given string that is easy to read for humans instead of being as short as possible. Strings without special characters are returned as they are, while all others are enclosed in double quotes, or in single quotes if they contain double quotes but no single quotes. Strings with control characters are ANSI-C quoted like in Quote.
+func QuoteReadable(str string) string {
+	if len(str) == 0 {
+		return `""`
+	}
+	if strings.IndexFunc(str, unicode.IsControl) >= 0 {
+		return QuoteANSIC(str)
+	}
+	if QuoteRaw(str) == str {
+		return str
+	}
+	if strings.ContainsRune(str, dqt) && !strings.ContainsRune(str, sqt) {
+		return QuoteSingle(str)
+	}
+	return QuoteDouble(str)
+}
+
+// GetCommandLineVerbose assembles a single command line like GetCommandLine, but quotes all arguments using QuoteReadable. The result is longer, but easier to read in logs and error messages, and is still parsed to the original command and arguments by Parse.
+func GetCommandLineVerbose(command string, args ...string) string {
+	return GetCommandLineStyle(StyleReadable, command, args...)
+}
+
+// GetCommandLineStyle assembles a single command line like GetCommandLine, but always quotes the command and all arguments using the given style.
+func GetCommandLineStyle(style QuoteStyle, command string, args ...string) string {
+	var sb strings.Builder
+	sb.WriteString(style.Quote(command))
 	for _, arg := range args {
 		sb.WriteRune(' ')
-		sb.WriteString(Quote(arg))
+		sb.WriteString(style.Quote(arg))
 	}
 	return sb.String()
 }
 
-// Quote returns a safe representation of the given string for command line calls.
+// Quote returns a safe representation of the given string for command line calls. Strings containing control characters like newlines are returned in ANSI-C quoting to avoid embedding them literally. Operators like ; | & < > are always quoted or escaped, so the result is a single word for ParseSequence, ParsePipeline and ParseConditional as well.
 func Quote(str string) string {
 	if len(str) == 0 {
 		return `""`
 	}
+	if strings.IndexFunc(str, unicode.IsControl) >= 0 {
+		return QuoteANSIC(str)
+	}
 
-	raw := quoteRaw(str)
-	single := quoteSingle(str)
-	double := quoteDouble(str)
+	raw := QuoteRaw(str)
+	single := QuoteSingle(str)
+	double := QuoteDouble(str)
 	if len(raw) < len(double) {
 		if len(single) < len(raw) {
 			return single
@@ -278,10 +1467,21 @@ func Quote(str string) string {
 	return double
 }
 
-func quoteRaw(str string) string {
+// QuoteRaw returns a representation of the given string that escapes special characters and operators using backslashes without any quotes. Empty strings are returned as "" because they cannot be represented without quotes. Line breaks are enclosed in double quotes because an escaped line break denotes a line continuation.
+func QuoteRaw(str string) string {
+	if len(str) == 0 {
+		return `""`
+	}
+
 	var sb strings.Builder
 	for _, r := range []rune(str) {
-		if unicode.IsSpace(r) || r == sqt || r == dqt || r == esc {
+		if r == '\n' {
+			sb.WriteRune(dqt)
+			sb.WriteRune(r)
+			sb.WriteRune(dqt)
+			continue
+		}
+		if unicode.IsSpace(r) || r == sqt || r == dqt || r == esc || strings.ContainsRune(operatorRunes, r) {
 			sb.WriteRune(esc)
 		}
 		sb.WriteRune(r)
@@ -289,7 +1489,13 @@ func quoteRaw(str string) string {
 	return sb.String()
 }
 
-func quoteSingle(str string) string {
+// EscapeArg returns a form of str that is safe to splice into a POSIX shell command. The result is always enclosed in single quotes, so no character is interpreted by the shell, and the bytes of str are preserved as they are. This is useful to embed user-provided values in hand-written command lines, for example in remote commands passed to ssh.
+func EscapeArg(str string) string {
+	return string(sqt) + strings.Replace(str, string(sqt), `'\''`, -1) + string(sqt)
+}
+
+// QuoteSingle returns a representation of the given string enclosed in single quotes.
+func QuoteSingle(str string) string {
 	var sb strings.Builder
 	sb.WriteRune(sqt)
 	for _, r := range []rune(str) {
@@ -307,7 +1513,8 @@ func quoteSingle(str string) string {
 	return sb.String()
 }
 
-func quoteDouble(str string) string {
+// QuoteDouble returns a representation of the given string enclosed in double quotes.
+func QuoteDouble(str string) string {
 	var sb strings.Builder
 	sb.WriteRune(dqt)
 	for _, r := range []rune(str) {
@@ -1,11 +1,21 @@
 package exec
 
 import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/sbreitf1/errors"
 	"github.com/stretchr/testify/assert"
+	"golang.org/x/text/encoding/unicode"
 )
 
 /* ############################################# */
@@ -36,7 +46,9 @@ func TestRunFail(t *testing.T) {
 
 func TestRunError(t *testing.T) {
 	_, _, err := Run(path("noexec.txt"))
-	assert.True(t, errors.InstanceOf(err, ErrRun))
+	assert.True(t, errors.InstanceOf(err, ErrPermissionDenied))
+	assert.Equal(t, KindRun, ErrorKind(err))
+	assert.Contains(t, err.Error(), path("noexec.txt"))
 }
 
 func TestShouldRunSuccess(t *testing.T) {
@@ -53,7 +65,181 @@ func TestShouldRunFail(t *testing.T) {
 
 func TestShouldRunError(t *testing.T) {
 	_, err := ShouldRun(path("noexec.sh"))
-	assert.True(t, errors.InstanceOf(err, ErrRun))
+	assert.True(t, errors.InstanceOf(err, ErrCommandNotFound))
+	assert.Equal(t, KindRun, ErrorKind(err))
+}
+
+func TestRunCommandNotFound(t *testing.T) {
+	_, _, err := Run("this-command-does-not-exist", "foo")
+	assert.True(t, errors.InstanceOf(err, ErrCommandNotFound))
+	assert.Contains(t, err.Error(), `"this-command-does-not-exist"`)
+
+	_, _, err = Run(path("missing.sh"))
+	assert.True(t, errors.InstanceOf(err, ErrCommandNotFound))
+	assert.Equal(t, KindRun, ErrorKind(err))
+}
+
+func TestRunMissingInterpreter(t *testing.T) {
+	_, _, err := Run(path("badinterpreter.sh"))
+	assert.False(t, errors.InstanceOf(err, ErrCommandNotFound))
+	assert.True(t, errors.InstanceOf(err, ErrBadInterpreter))
+	assert.Equal(t, KindRun, ErrorKind(err))
+}
+
+func TestRunBadInterpreter(t *testing.T) {
+	_, _, err := Run(path("crlf.sh"))
+	assert.True(t, errors.InstanceOf(err, ErrBadInterpreter))
+
+	_, _, err = Run(path("noshebang.sh"))
+	assert.True(t, errors.InstanceOf(err, ErrBadInterpreter))
+}
+
+func TestRunArgsTooLong(t *testing.T) {
+	args := make([]string, 64)
+	for i := range args {
+		args[i] = strings.Repeat("x", 64*1024)
+	}
+	_, _, err := Run(path("args.sh"), args...)
+	assert.True(t, errors.InstanceOf(err, ErrArgsTooLong))
+	assert.Equal(t, KindRun, ErrorKind(err))
+}
+
+func TestRunLineCanonical(t *testing.T) {
+	defer restoreDefaultExecutor(DefaultExecutor)
+	mock := NewMockExecutor(func(command string, args ...string) (string, int, errors.Error) { return "", 0, nil })
+	DefaultExecutor = mock
+
+	out, code, canonical, err := RunLineCanonical(`echo  "foo bar"   'it'"'"'s' \$HOME`)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "", out)
+	assert.Equal(t, `echo foo\ bar it\'s $HOME`, canonical)
+	assert.Equal(t, []CommandSpec{{Command: "echo", Args: []string{"foo bar", "it's", "$HOME"}}}, mock.Calls())
+
+	_, _, canonical, err = RunLineCanonical(`echo "foo`)
+	assert.True(t, errors.InstanceOf(err, ErrParse))
+	assert.Equal(t, "", canonical)
+}
+
+func TestCanonicalCommandLine(t *testing.T) {
+	a, err := CanonicalCommandLine(`ls -la "my dir"`)
+	assert.NoError(t, err)
+	b, err := CanonicalCommandLine(`ls   -la my\ dir`)
+	assert.NoError(t, err)
+	c, err := CanonicalCommandLine(`'ls' "-la" 'my dir'`)
+	assert.NoError(t, err)
+	assert.Equal(t, `ls -la my\ dir`, a)
+	assert.Equal(t, a, b)
+	assert.Equal(t, a, c)
+
+	_, err = CanonicalCommandLine("")
+	assert.True(t, errors.InstanceOf(err, ErrParse))
+}
+
+func TestSucceeds(t *testing.T) {
+	assert.True(t, Succeeds(path("success.sh")))
+	assert.False(t, Succeeds(path("fail.sh")))
+	assert.False(t, Succeeds(path("noexec.txt")))
+	assert.False(t, Succeeds("this-command-does-not-exist"))
+}
+
+func TestSucceedsLine(t *testing.T) {
+	assert.True(t, SucceedsLine(Quote(path("args.sh"))+" foo bar"))
+	assert.False(t, SucceedsLine(Quote(path("fail.sh"))))
+	assert.False(t, SucceedsLine(Quote(path("success.sh"))+` "unterminated`))
+}
+
+func TestRunMixed(t *testing.T) {
+	out, code, err := RunMixed([]string{path("args.sh"), "foo bar"}, `"baz qux"`)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, code)
+	assert.True(t, strings.Contains(out, "1foo bar ; 2baz qux"))
+
+	out, _, err = RunMixed(nil, Quote(path("args.sh"))+" a b")
+	assert.NoError(t, err)
+	assert.True(t, strings.Contains(out, "1a ; 2b"))
+}
+
+func TestRunMixedParseError(t *testing.T) {
+	_, _, err := RunMixed([]string{path("args.sh")}, `"foo`)
+	assert.Equal(t, ParseErrorUnterminatedDoubleQuote, ParseErrorKindOf(err))
+
+	_, _, err = RunMixed(nil, " ")
+	assert.Equal(t, ParseErrorEmptyInput, ParseErrorKindOf(err))
+}
+
+func TestRunArgs(t *testing.T) {
+	out, code, err := RunArgs(path("args.sh"), []string{"foo bar", `"baz"`})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, code)
+	assert.True(t, strings.Contains(out, `1foo bar ; 2"baz"`))
+}
+
+func TestRunLineFrom(t *testing.T) {
+	defer restoreDefaultExecutor(DefaultExecutor)
+	mock := NewMockExecutor(func(command string, args ...string) (string, int, errors.Error) {
+		return "", 0, nil
+	})
+	DefaultExecutor = mock
+
+	_, _, err := RunLineFrom([]string{"newcommand", "a b", `'c'`, ""})
+	assert.NoError(t, err)
+	_, _, err = RunLineFrom([]string{"single"})
+	assert.NoError(t, err)
+	calls := mock.Calls()
+	if assert.Len(t, calls, 2) {
+		assert.Equal(t, "newcommand", calls[0].Command)
+		assert.Equal(t, []string{"a b", `'c'`, ""}, calls[0].Args)
+		assert.Equal(t, "single", calls[1].Command)
+		assert.Empty(t, calls[1].Args)
+	}
+
+	_, _, err = RunLineFrom(nil)
+	assert.Equal(t, ParseErrorEmptyInput, ParseErrorKindOf(err))
+}
+
+func TestRunBytes(t *testing.T) {
+	out, code, err := RunBytes(path("binary.sh"))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, code)
+	assert.Equal(t, []byte{0, 1, 255, '\n'}, out)
+}
+
+func TestRunBytesUnprocessed(t *testing.T) {
+	e := NewLocalExecutor(
+		WithOutputEncoding(unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM)),
+		WithStripANSI(true),
+		WithNormalizeNewlines(true),
+		WithOutputFilter(strings.ToUpper),
+	)
+	out, code, err := e.RunBytes(path("binary.sh"))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, code)
+	assert.Equal(t, []byte{0, 1, 255, '\n'}, out)
+
+	out, _, err = e.RunBytes("printf", `\033[1mbold\033[0m\r\n`)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("\x1b[1mbold\x1b[0m\r\n"), out)
+}
+
+func TestRunTimed(t *testing.T) {
+	out, code, duration, err := RunTimed(path("sleep.sh"))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, code)
+	assert.True(t, strings.Contains(out, "awake"))
+	assert.True(t, duration >= 300*time.Millisecond)
+}
+
+func TestRunTimedMock(t *testing.T) {
+	defer restoreDefaultExecutor(DefaultExecutor)
+	DefaultExecutor = NewMockExecutor(func(command string, args ...string) (string, int, errors.Error) {
+		time.Sleep(50 * time.Millisecond)
+		return "foobar", 0, nil
+	})
+	out, _, duration, err := RunTimed("newcommand")
+	assert.NoError(t, err)
+	assert.Equal(t, "foobar", out)
+	assert.True(t, duration >= 50*time.Millisecond)
 }
 
 /* ############################################# */
@@ -74,6 +260,44 @@ func TestParseCommandOnly(t *testing.T) {
 	assert.Nil(t, args)
 }
 
+func TestParseSingleRuneTokens(t *testing.T) {
+	cmd, args, err := Parse(`a b c`)
+	assert.NoError(t, err)
+	assert.Equal(t, "a", cmd)
+	assert.Equal(t, []string{"b", "c"}, args)
+
+	cmd, args, err = Parse(`x`)
+	assert.NoError(t, err)
+	assert.Equal(t, "x", cmd)
+	assert.Nil(t, args)
+
+	cmd, args, err = Parse(` x `)
+	assert.NoError(t, err)
+	assert.Equal(t, "x", cmd)
+	assert.Nil(t, args)
+
+	cmd, args, err = Parse(`newcommand -d foo z`)
+	assert.NoError(t, err)
+	assert.Equal(t, "newcommand", cmd)
+	assert.Equal(t, []string{"-d", "foo", "z"}, args)
+
+	cmd, args, err = Parse(`newcommand  m  -d`)
+	assert.NoError(t, err)
+	assert.Equal(t, "newcommand", cmd)
+	assert.Equal(t, []string{"m", "-d"}, args)
+}
+
+func TestParseSingleRuneQuotedTokens(t *testing.T) {
+	cmd, args, err := Parse(`"a" 'b' \c $'d' " " ' ' \  "" ''`)
+	assert.NoError(t, err)
+	assert.Equal(t, "a", cmd)
+	assert.Equal(t, []string{"b", "c", "d", " ", " ", " ", "", ""}, args)
+
+	fields, err := Fields(`'' "" x`)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"", "", "x"}, fields)
+}
+
 func TestParseSpaces(t *testing.T) {
 	cmd, args, err := Parse(`newcommand    -d    foo    -m   bar    `)
 	assert.NoError(t, err)
@@ -116,6 +340,59 @@ func TestParseEmptyQuotes(t *testing.T) {
 	assert.Equal(t, []string{"-d", "", "-m", ""}, args)
 }
 
+func TestFields(t *testing.T) {
+	fields, err := Fields(`newcommand -d "foo bar" '' x\ y`)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"newcommand", "-d", "foo bar", "", "x y"}, fields)
+}
+
+func TestFieldsEmpty(t *testing.T) {
+	fields, err := Fields("   ")
+	assert.NoError(t, err)
+	assert.Empty(t, fields)
+}
+
+func TestFieldsError(t *testing.T) {
+	_, err := Fields(`newcommand "test`)
+	assert.True(t, errors.InstanceOf(err, ErrParse))
+}
+
+func TestParseWithEnvAssignments(t *testing.T) {
+	env, cmd, args, err := ParseWithEnvAssignments(`FOO=bar _BAZ1="a b" EMPTY= EQ=a=b newcommand -d X=1`)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"FOO": "bar", "_BAZ1": "a b", "EMPTY": "", "EQ": "a=b"}, env)
+	assert.Equal(t, "newcommand", cmd)
+	assert.Equal(t, []string{"-d", "X=1"}, args)
+
+	env, cmd, args, err = ParseWithEnvAssignments(`newcommand`)
+	assert.NoError(t, err)
+	assert.Empty(t, env)
+	assert.Equal(t, "newcommand", cmd)
+	assert.Nil(t, args)
+}
+
+func TestParseWithEnvAssignmentsNoName(t *testing.T) {
+	for _, commandLine := range []string{`"FOO=bar" x`, `'FOO'=bar x`, `F\OO=bar x`, `1FOO=bar x`, `=bar x`, `FOO-1=bar x`} {
+		env, cmd, args, err := ParseWithEnvAssignments(commandLine)
+		assert.NoError(t, err)
+		assert.Empty(t, env, commandLine)
+		assert.Equal(t, []string{"x"}, args, commandLine)
+		assert.Contains(t, cmd, "=", commandLine)
+	}
+}
+
+func TestParseWithEnvAssignmentsFail(t *testing.T) {
+	_, _, _, err := ParseWithEnvAssignments(`FOO=bar`)
+	assert.True(t, errors.InstanceOf(err, ErrParse))
+	assert.Equal(t, ParseErrorSyntax, ParseErrorKindOf(err))
+
+	_, _, _, err = ParseWithEnvAssignments(`  `)
+	assert.Equal(t, ParseErrorEmptyInput, ParseErrorKindOf(err))
+
+	_, _, _, err = ParseWithEnvAssignments(`FOO="bar`)
+	assert.Equal(t, ParseErrorUnterminatedDoubleQuote, ParseErrorKindOf(err))
+}
+
 func TestParseCombinedQuotes(t *testing.T) {
 	cmd, args, err := Parse(`newcommand -d "asdf"'qwert'foo'bar'\ "test""1234"\ `)
 	assert.NoError(t, err)
@@ -130,6 +407,108 @@ func TestParseQuoteEscape(t *testing.T) {
 	assert.Equal(t, []string{"a\\b", "a;b", "a\\;b", "a\\;b", "\\'\\n\"blub", "\\\"\\n\\ blub", "foo\\bar\\ \\t\\n\\0", "foo\\\\bar"}, args)
 }
 
+func TestParseANSICQuotes(t *testing.T) {
+	cmd, args, err := Parse(`newcommand $'line1\nline2' $'tab\there' pre$'\x41\u00e4'post $'it\'s' $'\q' '$'`)
+	assert.NoError(t, err)
+	assert.Equal(t, "newcommand", cmd)
+	assert.Equal(t, []string{"line1\nline2", "tab\there", "preAäpost", "it's", "\\q", "$"}, args)
+}
+
+func TestParseANSICQuotesExtended(t *testing.T) {
+	_, args, err := Parse(`newcommand $'\101\0\7x\1019' $'\e[0m\E' $'\U0001F600\u20ac' $'\cA\cz\c?' $'what\?' $'\"\\'`)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"A\000\ax\x419", "\x1b[0m\x1b", "😀€", "\x01\x1a\x7f", "what?", `"\`}, args)
+}
+
+func TestParseANSICQuotesBytes(t *testing.T) {
+	_, args, err := Parse(`newcommand $'\377\xff'`)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"\xff\xff"}, args)
+}
+
+func TestParseANSICQuoteFail(t *testing.T) {
+	_, _, err := Parse(`newcommand $'test`)
+	assert.True(t, errors.InstanceOf(err, ErrParse))
+}
+
+func TestParseWith(t *testing.T) {
+	cfg := DefaultParseConfig()
+	cfg.SingleQuote = '`'
+	cmd, args, err := ParseWith("newcommand `foo 'bar` 'baz' \\` \"a \\\" b\"", cfg)
+	assert.NoError(t, err)
+	assert.Equal(t, "newcommand", cmd)
+	assert.Equal(t, []string{"foo 'bar", "'baz'", "`", "a \" b"}, args)
+}
+
+func TestParseWithCustomEscape(t *testing.T) {
+	cfg := ParseConfig{SingleQuote: '\'', DoubleQuote: '"', Escape: '^'}
+	cmd, args, err := ParseWith(`copy C:\a\b C:\target^ dir "^"x^"" 'it''s'`, cfg)
+	assert.NoError(t, err)
+	assert.Equal(t, "copy", cmd)
+	assert.Equal(t, []string{`C:\a\b`, `C:\target dir`, `"x"`, "its"}, args)
+}
+
+func TestParseWithDisableEscape(t *testing.T) {
+	cfg := DefaultParseConfig()
+	cfg.DisableEscape = true
+	cmd, args, err := ParseWith(`copy C:\a\ C:\b "C:\Program Files\" 'x\'`, cfg)
+	assert.NoError(t, err)
+	assert.Equal(t, "copy", cmd)
+	assert.Equal(t, []string{`C:\a\`, `C:\b`, `C:\Program Files\`, `x\`}, args)
+
+	// ANSI-C quotes still decode escape sequences
+	_, args, err = ParseWith(`echo $'a\tb'`, cfg)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a\tb"}, args)
+}
+
+func TestParseLineContinuation(t *testing.T) {
+	cmd, args, err := Parse("newcommand \\\n  -d foo\\\nbar \\\r\n\\\n -m \"a \\\nb\" 'c \\\nd' \\\n")
+	assert.NoError(t, err)
+	assert.Equal(t, "newcommand", cmd)
+	assert.Equal(t, []string{"-d", "foobar", "-m", "a b", "c \\\nd"}, args)
+}
+
+func TestParseLineContinuationDisableEscape(t *testing.T) {
+	cfg := DefaultParseConfig()
+	cfg.DisableEscape = true
+	_, args, err := ParseWith("dir C:\\\n", cfg)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"C:\\"}, args)
+}
+
+func TestParseWithInvalidConfig(t *testing.T) {
+	_, _, err := ParseWith("newcommand", ParseConfig{SingleQuote: '"', DoubleQuote: '"', Escape: '\\'})
+	assert.True(t, errors.InstanceOf(err, ErrParse))
+	_, _, err = ParseWith("newcommand", ParseConfig{SingleQuote: ' ', DoubleQuote: '"', Escape: '\\'})
+	assert.True(t, errors.InstanceOf(err, ErrParse))
+}
+
+func TestParseLimited(t *testing.T) {
+	cmd, args, err := ParseLimited(`newcommand -d "foo bar"`, 3, 23)
+	assert.NoError(t, err)
+	assert.Equal(t, "newcommand", cmd)
+	assert.Equal(t, []string{"-d", "foo bar"}, args)
+}
+
+func TestParseLimitedTokens(t *testing.T) {
+	_, _, err := ParseLimited(`newcommand -d "foo bar"`, 2, 0)
+	assert.True(t, errors.InstanceOf(err, ErrParse))
+
+	_, _, err = ParseLimited(strings.Repeat("a ", 100000), 10, 0)
+	assert.True(t, errors.InstanceOf(err, ErrParse))
+}
+
+func TestParseLimitedLength(t *testing.T) {
+	_, _, err := ParseLimited(`newcommand -d "foo bar"`, 0, 22)
+	assert.True(t, errors.InstanceOf(err, ErrParse))
+}
+
+func TestParseLimitedInvalid(t *testing.T) {
+	_, _, err := ParseLimited(`newcommand`, -1, 0)
+	assert.True(t, errors.InstanceOf(err, ErrParse))
+}
+
 func TestParseEmpty(t *testing.T) {
 	_, _, err := Parse(``)
 	assert.True(t, errors.InstanceOf(err, ErrParse))
@@ -183,7 +562,8 @@ func TestRunLineFail(t *testing.T) {
 
 func TestRunLineError(t *testing.T) {
 	_, _, err := RunLine(Quote(path("noexec.txt")))
-	assert.True(t, errors.InstanceOf(err, ErrRun))
+	assert.True(t, errors.InstanceOf(err, ErrPermissionDenied))
+	assert.Equal(t, KindRun, ErrorKind(err))
 }
 
 func TestRunLineParseError(t *testing.T) {
@@ -205,7 +585,8 @@ func TestShouldRunLineFail(t *testing.T) {
 
 func TestShouldRunLineError(t *testing.T) {
 	_, err := ShouldRunLine(Quote(path("noexec.txt")))
-	assert.True(t, errors.InstanceOf(err, ErrRun))
+	assert.True(t, errors.InstanceOf(err, ErrPermissionDenied))
+	assert.Equal(t, KindRun, ErrorKind(err))
 }
 
 /* ############################################# */
@@ -222,6 +603,69 @@ func TestGetCommandLineNoQuotes(t *testing.T) {
 	assert.Equal(t, "newcommand blub foobar", cmdLine)
 }
 
+func TestGetCommandLineParts(t *testing.T) {
+	parts := GetCommandLineParts("newcommand", "blub", "", "foo bar", `it's`, "a\nb")
+	assert.Equal(t, []string{"newcommand", "blub", `""`, `foo\ bar`, `it\'s`, `$'a\nb'`}, parts)
+	assert.Equal(t, GetCommandLine("newcommand", "blub", "", "foo bar", `it's`, "a\nb"), strings.Join(parts, " "))
+}
+
+func TestGetCommandLineStyle(t *testing.T) {
+	args := []string{"blub", "", "foo bar", `it's`}
+	assert.Equal(t, `newcommand blub "" foo\ bar it\'s`, GetCommandLineStyle(StyleRaw, "newcommand", args...))
+	assert.Equal(t, `'newcommand' 'blub' '' 'foo bar' 'it'\''s'`, GetCommandLineStyle(StyleSingle, "newcommand", args...))
+	assert.Equal(t, `"newcommand" "blub" "" "foo bar" "it's"`, GetCommandLineStyle(StyleDouble, "newcommand", args...))
+	assert.Equal(t, GetCommandLine("newcommand", args...), GetCommandLineStyle(StyleAuto, "newcommand", args...))
+}
+
+func TestGetCommandLineVerbose(t *testing.T) {
+	assert.Equal(t, `newcommand blub "" "foo bar" "it's" 'say "hi"' "both ' and \"" "C:\\dir" $'a\nb'`,
+		GetCommandLineVerbose("newcommand", "blub", "", "foo bar", `it's`, `say "hi"`, `both ' and "`, `C:\dir`, "a\nb"))
+	assert.Equal(t, `"my command" --flag`, GetCommandLineVerbose("my command", "--flag"))
+	assert.Equal(t, GetCommandLineVerbose("x", "a b"), GetCommandLineStyle(StyleReadable, "x", "a b"))
+}
+
+func TestGetCommandLineStyleRoundTrip(t *testing.T) {
+	args := []string{"blub", "", "foo bar", `"test  `, `blub''\`, `"""`}
+	for _, style := range []QuoteStyle{StyleAuto, StyleRaw, StyleSingle, StyleDouble, StyleReadable} {
+		cmd, parsedArgs, err := Parse(GetCommandLineStyle(style, "newcommand", args...))
+		assert.NoError(t, err)
+		assert.Equal(t, "newcommand", cmd)
+		assert.Equal(t, args, parsedArgs)
+	}
+}
+
+func TestQuoteControlCharacters(t *testing.T) {
+	assert.Equal(t, `$'line1\nline2'`, Quote("line1\nline2"))
+	assert.Equal(t, `$'a\tb\r\x07\u0085\\\''`, Quote("a\tb\r\a\u0085\\'"))
+	assert.Equal(t, `$'foo\nbar'`, StyleANSIC.Quote("foo\nbar"))
+}
+
+func TestQuoteControlCharactersRoundTrip(t *testing.T) {
+	values := []string{"line1\nline2", "\n", "tab\there", "\r\n", "bell\a", "\x01\x1f\x7f", "next\u0085line", "mixed 'single' \n \"double\" \\ $'x'"}
+	for _, value := range values {
+		cmd, args, err := Parse("newcommand " + Quote(value))
+		assert.NoError(t, err)
+		assert.Equal(t, "newcommand", cmd)
+		assert.Equal(t, []string{value}, args)
+	}
+}
+
+func TestQuoteStyles(t *testing.T) {
+	assert.Equal(t, `foo\ \"bar\"`, QuoteRaw(`foo "bar"`))
+	assert.Equal(t, `'foo "bar"'`, QuoteSingle(`foo "bar"`))
+	assert.Equal(t, `"foo \"bar\""`, QuoteDouble(`foo "bar"`))
+	assert.Equal(t, `""`, QuoteRaw(""))
+	assert.Equal(t, `''`, QuoteSingle(""))
+	assert.Equal(t, `""`, QuoteDouble(""))
+}
+
+func TestQuoteOperators(t *testing.T) {
+	assert.Equal(t, `"x&&rm"`, Quote("x&&rm"))
+	assert.Equal(t, `a\;b\|c\<d\>e`, QuoteRaw("a;b|c<d>e"))
+	assert.Equal(t, `"x&&rm"`, QuoteReadable("x&&rm"))
+	assert.Equal(t, `echo "x&&rm"`, GetCommandLine("echo", "x&&rm"))
+}
+
 /* ############################################# */
 /* ###                Objects                ### */
 /* ############################################# */
@@ -274,6 +718,211 @@ func TestMockExecutorRunLine(t *testing.T) {
 	assert.Equal(t, []string{"foo", "bar"}, lastArgs)
 }
 
+func TestMockExecutorRunBytes(t *testing.T) {
+	e := NewMockBytesExecutor(func(command string, args ...string) ([]byte, int, errors.Error) {
+		return []byte{0, 255}, 0, nil
+	})
+	out, code, err := e.RunBytes("newcommand")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, code)
+	assert.Equal(t, []byte{0, 255}, out)
+
+	str, _, _ := e.Run("newcommand")
+	assert.Equal(t, "\000\377", str)
+}
+
+func TestMockExecutorRunBytesFromString(t *testing.T) {
+	e := NewMockExecutor(func(command string, args ...string) (string, int, errors.Error) {
+		return "foobar", 42, nil
+	})
+	out, code, err := e.RunBytes("newcommand")
+	assert.NoError(t, err)
+	assert.Equal(t, 42, code)
+	assert.Equal(t, []byte("foobar"), out)
+}
+
+func TestMockExecutorDelay(t *testing.T) {
+	e := NewMockExecutor(func(command string, args ...string) (string, int, errors.Error) {
+		return "foobar", 0, nil
+	})
+	e.Delay = 100 * time.Millisecond
+
+	start := time.Now()
+	out, _, err := e.Run("newcommand")
+	assert.NoError(t, err)
+	assert.Equal(t, "foobar", out)
+	assert.True(t, time.Since(start) >= 100*time.Millisecond)
+}
+
+func TestMockExecutorRunContext(t *testing.T) {
+	e := NewMockExecutor(func(command string, args ...string) (string, int, errors.Error) {
+		return "foobar", 0, nil
+	})
+	e.Delay = 50 * time.Millisecond
+
+	out, _, err := e.RunContext(context.Background(), "newcommand")
+	assert.NoError(t, err)
+	assert.Equal(t, "foobar", out)
+}
+
+func TestMockExecutorRunContextCancel(t *testing.T) {
+	e := NewMockExecutor(func(command string, args ...string) (string, int, errors.Error) {
+		assert.Fail(t, "Callback should not be executed on cancel")
+		return "", 0, nil
+	})
+	e.Delay = time.Hour
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, _, err := e.RunContext(ctx, "newcommand")
+	assert.True(t, errors.InstanceOf(err, ErrTimeout))
+
+	ctx, cancel = context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	_, _, err = e.RunContext(ctx, "newcommand")
+	assert.True(t, errors.InstanceOf(err, ErrCanceled))
+}
+
+func TestMockExecutorRunContextAlreadyDone(t *testing.T) {
+	e := NewMockExecutor(func(command string, args ...string) (string, int, errors.Error) {
+		assert.Fail(t, "Callback should not be executed for a done context")
+		return "", 0, nil
+	})
+
+	// without delay, the timer fires immediately as well and must not win
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for i := 0; i < 100; i++ {
+		_, _, err := e.RunContext(ctx, "newcommand")
+		assert.True(t, errors.InstanceOf(err, ErrCanceled))
+	}
+}
+
+func TestMockExecutorReturnSequence(t *testing.T) {
+	e := NewMockExecutor(func(command string, args ...string) (string, int, errors.Error) {
+		return "fallback", 0, nil
+	})
+	e.ReturnSequence("flaky", MockResult{Output: "first", ExitCode: 1}, MockResult{Err: ErrRun.Make()})
+
+	out, code, err := e.Run("flaky", "foo")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, code)
+	assert.Equal(t, "first", out)
+	_, _, err = e.RunLine("flaky bar")
+	assert.True(t, errors.InstanceOf(err, ErrRun))
+	out, code, err = e.Run("flaky")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "fallback", out)
+}
+
+func TestMockExecutorReturnSequenceRetry(t *testing.T) {
+	e := NewMockBytesExecutor(func(command string, args ...string) ([]byte, int, errors.Error) {
+		return []byte("success"), 0, nil
+	})
+	e.ReturnSequence("flaky", MockResult{ExitCode: 1}, MockResult{ExitCode: 2})
+
+	out, code, err := NewRetryExecutor(e, 3).RunBytes("flaky")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, code)
+	assert.Equal(t, []byte("success"), out)
+}
+
+func TestMockExecutorCalls(t *testing.T) {
+	e := NewMockExecutor(func(command string, args ...string) (string, int, errors.Error) {
+		return "", 0, nil
+	})
+	e.Run("foo", "a", "b")
+	e.RunLine(`bar "c d"`)
+	e.RunLine(`bar "c d`)
+	e.RunBytes("baz")
+	assert.Equal(t, []CommandSpec{{"foo", []string{"a", "b"}}, {"bar", []string{"c d"}}, {"baz", nil}}, e.Calls())
+}
+
+func TestMockExecutorConcurrent(t *testing.T) {
+	e := NewMockExecutor(func(command string, args ...string) (string, int, errors.Error) {
+		return "fallback", 0, nil
+	})
+	e.ReturnSequence("cmd", MockResult{Output: "a"}, MockResult{Output: "b"}, MockResult{Output: "c"})
+
+	commands := make([]CommandSpec, 50)
+	for i := range commands {
+		commands[i] = CommandSpec{Command: "cmd"}
+	}
+	results := runAll(e, 10, commands, nil, batchConfig{})
+
+	outputs := make(map[string]int)
+	for _, result := range results {
+		outputs[result.Output]++
+	}
+	assert.Equal(t, map[string]int{"a": 1, "b": 1, "c": 1, "fallback": 47}, outputs)
+	assert.Equal(t, 50, len(e.Calls()))
+}
+
+func TestRunContextDefaultExecutor(t *testing.T) {
+	defer restoreDefaultExecutor(DefaultExecutor)
+	DefaultExecutor = NewMockExecutor(func(command string, args ...string) (string, int, errors.Error) {
+		return "foobar", 0, nil
+	})
+	DefaultExecutor.(*MockExecutor).Delay = time.Hour
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, _, err := RunContext(ctx, "newcommand")
+	assert.True(t, errors.InstanceOf(err, ErrCanceled))
+}
+
+func TestMockExecutorDefaultResult(t *testing.T) {
+	e := NewMockExecutor(nil)
+	e.ReturnSequence("git", MockResult{Output: "main\n"})
+
+	out, code, err := e.Run("git", "branch")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "main\n", out)
+
+	// unmatched commands succeed without output by default
+	out, code, err = e.Run("git", "push")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "", out)
+
+	e.DefaultResult = MockResult{Output: "ok", ExitCode: 3}
+	out, code, err = e.RunLine("make all")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, code)
+	assert.Equal(t, "ok", out)
+	bytesOut, code, err := e.RunBytes("make")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, code)
+	assert.Equal(t, []byte("ok"), bytesOut)
+	assert.Len(t, e.Calls(), 4)
+}
+
+func TestMockExecutorStrict(t *testing.T) {
+	e := NewMockExecutor(nil)
+	e.Strict = true
+	e.ReturnSequence("git", MockResult{Output: "main\n"})
+
+	out, _, err := e.Run("git", "branch")
+	assert.NoError(t, err)
+	assert.Equal(t, "main\n", out)
+
+	_, _, err = e.Run("git", "push", "--force")
+	assert.True(t, errors.InstanceOf(err, ErrUnexpectedCommand))
+	assert.True(t, strings.Contains(err.Error(), "git push --force"))
+	_, _, err = e.RunBytes("rm")
+	assert.True(t, errors.InstanceOf(err, ErrUnexpectedCommand))
+
+	// callbacks still answer all commands
+	e.RunCallback = func(command string, args ...string) (string, int, errors.Error) {
+		return "callback", 0, nil
+	}
+	out, _, err = e.Run("rm")
+	assert.NoError(t, err)
+	assert.Equal(t, "callback", out)
+}
+
 func TestMockExecutorRunLineParseFail(t *testing.T) {
 	e := NewMockExecutor(func(command string, args ...string) (string, int, errors.Error) {
 		assert.Fail(t, "Callback should not be executed on parse fail")
@@ -283,6 +932,344 @@ func TestMockExecutorRunLineParseFail(t *testing.T) {
 	assert.True(t, errors.InstanceOf(err, ErrParse))
 }
 
+func TestLocalExecutorEnv(t *testing.T) {
+	e := NewLocalExecutor(WithEnv(map[string]string{"LANG": "de_DE.UTF-8"}))
+	out, code, err := e.Run(path("locale.sh"))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, code)
+	assert.True(t, strings.Contains(out, "LANG=de_DE.UTF-8"))
+}
+
+func TestLocalExecutorEnvAssignments(t *testing.T) {
+	e := NewLocalExecutor(WithEnv(map[string]string{"BAR": "bar"}), WithEnvAssignments())
+	out, code, err := e.RunLine(`FOO="foo bar" sh -c 'echo $FOO $BAR'`)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "foo bar bar\n", out)
+
+	// assignments only apply to a single command
+	out, _, err = e.RunLine(`sh -c 'echo $FOO $BAR'`)
+	assert.NoError(t, err)
+	assert.Equal(t, "bar\n", out)
+
+	_, _, err = NewLocalExecutor().RunLine(`FOO=bar sh -c 'echo $FOO'`)
+	assert.True(t, errors.InstanceOf(err, ErrCommandNotFound))
+}
+
+func TestLocalExecutorDir(t *testing.T) {
+	dir := t.TempDir()
+	e := NewLocalExecutor(WithDir(dir))
+	out, code, err := e.Run("pwd")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, code)
+	assert.Equal(t, dir+"\n", out)
+
+	out, _, err = e.With(WithDir("/")).Run("pwd")
+	assert.NoError(t, err)
+	assert.Equal(t, "/\n", out)
+
+	// defaults must not be altered by call-level options
+	out, _, _ = e.Run("pwd")
+	assert.Equal(t, dir+"\n", out)
+}
+
+func TestLocalExecutorWith(t *testing.T) {
+	e := NewLocalExecutor(WithEnv(map[string]string{"FOO": "foo", "BAR": "bar"}), WithOutputFilter(strings.ToUpper))
+
+	out, _, err := e.With(WithEnv(map[string]string{"BAR": "override"})).Run("sh", "-c", "echo $FOO $BAR")
+	assert.NoError(t, err)
+	assert.Equal(t, "FOO OVERRIDE\n", out)
+
+	out, _, err = e.With(WithCleanEnv(map[string]string{"BAR": "clean"})).Run("sh", "-c", "echo $FOO $BAR")
+	assert.NoError(t, err)
+	assert.Equal(t, "CLEAN\n", out)
+
+	out, _, err = e.With(WithOutputFilter(func(s string) string { return strings.Replace(s, "O", "0", -1) })).Run("sh", "-c", "echo $FOO")
+	assert.NoError(t, err)
+	assert.Equal(t, "F00\n", out)
+
+	out, _, _ = e.Run("sh", "-c", "echo $FOO $BAR")
+	assert.Equal(t, "FOO BAR\n", out)
+}
+
+func TestLocalExecutorCleanEnv(t *testing.T) {
+	e := NewLocalExecutor(WithEnv(map[string]string{"DROPPED": "1"}), WithCleanEnv(map[string]string{"FOO": "bar"}), WithEnv(map[string]string{"BAR": "baz"}))
+	out, code, err := e.Run("env")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "PATH=/usr/local/bin:/usr/bin:/bin\nBAR=baz\nFOO=bar\n", out)
+}
+
+func TestLocalExecutorCleanEnvPath(t *testing.T) {
+	dir := t.TempDir()
+	e := NewLocalExecutor(WithCleanEnv(map[string]string{"PATH": dir}))
+	_, _, err := e.Run("sh", "-c", "exit 0")
+	assert.True(t, errors.InstanceOf(err, ErrCommandNotFound))
+
+	// commands with path are not looked up
+	out, code, err := e.Run(path("success.sh"))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, code)
+	assert.True(t, strings.Contains(out, "some test output here"))
+
+	e = NewLocalExecutor(WithCleanEnv(map[string]string{"PATH": dir + ":/bin:/usr/bin"}))
+	out, _, err = e.Run("sh", "-c", `echo "$0 $PATH"`)
+	assert.NoError(t, err)
+	assert.Equal(t, "sh "+dir+":/bin:/usr/bin\n", out)
+}
+
+func TestLocalExecutorRunC(t *testing.T) {
+	e := NewLocalExecutor(WithEnv(map[string]string{"LC_ALL": "de_DE.UTF-8", "LANG": "de_DE.UTF-8"}))
+	out, code, err := e.RunC(path("locale.sh"))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, code)
+	assert.True(t, strings.Contains(out, "LC_ALL=C LANG=C"))
+	assert.True(t, strings.Contains(out, "A B a b"))
+
+	// configured environment must not be altered by RunC
+	out, _, _ = e.Run(path("locale.sh"))
+	assert.True(t, strings.Contains(out, "LC_ALL=de_DE.UTF-8"))
+}
+
+func TestRunCMock(t *testing.T) {
+	defer restoreDefaultExecutor(DefaultExecutor)
+	var lastCommand string
+	var lastArgs []string
+	DefaultExecutor = NewMockExecutor(func(command string, args ...string) (string, int, errors.Error) {
+		lastCommand = command
+		lastArgs = args
+		return "", 0, nil
+	})
+	RunC("ls", "-l")
+	assert.Equal(t, "ls", lastCommand)
+	assert.Equal(t, []string{"-l"}, lastArgs)
+}
+
+func TestRunCShell(t *testing.T) {
+	defer restoreDefaultExecutor(DefaultExecutor)
+	DefaultExecutor = NewShellExecutor()
+	out, code, err := RunC(path("locale.sh"))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, code)
+	assert.True(t, strings.Contains(out, "LC_ALL=C LANG=C"))
+}
+
+func TestLocalExecutorExecute(t *testing.T) {
+	result := NewLocalExecutor().Execute(context.Background(), path("large.sh"))
+	assert.NoError(t, result.Err)
+	assert.Equal(t, 0, result.ExitCode)
+	assert.Equal(t, 1100, len(result.Output))
+	assert.Equal(t, 1100, result.OutputBytes)
+	assert.False(t, result.Truncated)
+}
+
+func TestLocalExecutorMaxOutput(t *testing.T) {
+	result := NewLocalExecutor(WithMaxOutput(25)).Execute(context.Background(), path("large.sh"))
+	assert.NoError(t, result.Err)
+	assert.Equal(t, 0, result.ExitCode)
+	assert.Equal(t, "0123456789\n0123456789\n012", result.Output)
+	assert.Equal(t, 1100, result.OutputBytes)
+	assert.True(t, result.Truncated)
+
+	result = NewLocalExecutor(WithMaxOutput(1100)).Execute(context.Background(), path("large.sh"))
+	assert.Equal(t, 1100, len(result.Output))
+	assert.False(t, result.Truncated)
+}
+
+func TestLocalExecutorCmdConfigurator(t *testing.T) {
+	var configured *exec.Cmd
+	e := NewLocalExecutor(WithEnv(map[string]string{"FOO": "bar"}), WithCmdConfigurator(func(cmd *exec.Cmd) {
+		configured = cmd
+		cmd.Dir = "/"
+	}), WithCmdConfigurator(func(cmd *exec.Cmd) {
+		cmd.Env = append(cmd.Env, "BAR=baz")
+	}))
+
+	out, code, err := e.Run("sh", "-c", "pwd; echo $FOO $BAR")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "/\nbar baz\n", out)
+	assert.NotNil(t, configured)
+}
+
+func TestLocalExecutorTailBuffer(t *testing.T) {
+	var buf bytes.Buffer
+	e := NewLocalExecutor(WithTailBuffer(25))
+	out, code, err := e.RunStream(&buf, path("large.sh"))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "89\n0123456789\n0123456789\n", out)
+	assert.Equal(t, 1100, buf.Len())
+
+	result := NewLocalExecutor(WithMaxOutput(10), WithTailBuffer(12)).Execute(context.Background(), path("large.sh"))
+	assert.Equal(t, "\n0123456789\n", result.Output)
+	assert.Equal(t, 1100, result.OutputBytes)
+	assert.True(t, result.Truncated)
+}
+
+func TestOutputBufferTail(t *testing.T) {
+	b := &outputBuffer{tail: 4}
+	b.Write([]byte("ab"))
+	assert.Equal(t, "ab", b.String())
+	b.Write([]byte("cde"))
+	assert.Equal(t, "bcde", b.String())
+	b.Write([]byte("0123456789"))
+	assert.Equal(t, "6789", b.String())
+	assert.Equal(t, 15, b.Total())
+}
+
+func TestLocalExecutorOutputHint(t *testing.T) {
+	out, code, err := NewLocalExecutor(WithOutputHint(4096)).Run(path("large.sh"))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, code)
+	assert.Equal(t, strings.Repeat("0123456789\n", 100), out)
+
+	b := NewLocalExecutor(WithOutputHint(4096), WithMaxOutput(100)).newOutputBuffer(nil)
+	assert.True(t, b.buffer.Cap() >= 100 && b.buffer.Cap() < 4096)
+}
+
+func BenchmarkOutputBuffer(b *testing.B) {
+	chunk := []byte(strings.Repeat("0123456789abcdef", 256))
+	for _, hint := range []int{0, 1 << 20} {
+		e := NewLocalExecutor(WithOutputHint(hint))
+		b.Run("hint="+strconv.Itoa(hint), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				buf := e.newOutputBuffer(nil)
+				for written := 0; written < 1<<20; written += len(chunk) {
+					buf.Write(chunk)
+				}
+			}
+		})
+	}
+}
+
+func TestLocalExecutorFatalOutputPatterns(t *testing.T) {
+	defer restoreDefaultExecutor(DefaultExecutor)
+	DefaultExecutor = NewLocalExecutor(WithFatalOutputPatterns(regexp.MustCompile(`(?m)^FATAL:`), regexp.MustCompile(`some test output`)))
+
+	_, err := ShouldRun(path("success.sh"))
+	assert.True(t, errors.InstanceOf(err, ErrFatalOutput))
+	assert.True(t, strings.Contains(err.Error(), "some test output"))
+
+	_, err = ShouldRun(path("fail.sh"))
+	assert.True(t, errors.InstanceOf(err, ErrReturnCode))
+
+	_, err = ShouldRunLine(Quote(path("args.sh")) + " foo bar")
+	assert.NoError(t, err)
+}
+
+func TestLocalExecutorExitCodeParser(t *testing.T) {
+	pattern := regexp.MustCompile(`(?m)^EXIT:(\d+)$`)
+	e := NewLocalExecutor(WithExitCodeParser(func(output string) (int, bool) {
+		m := pattern.FindStringSubmatch(output)
+		if m == nil {
+			return 0, false
+		}
+		code, err := strconv.Atoi(m[1])
+		return code, err == nil
+	}))
+
+	out, code, err := e.Run(path("sentinel.sh"), "4")
+	assert.NoError(t, err)
+	assert.Equal(t, 4, code)
+	assert.True(t, strings.Contains(out, "EXIT:4"))
+
+	_, code, err = e.Run(path("sentinel.sh"), "none")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, code)
+
+	// real non-zero exit codes take precedence
+	_, code, err = e.Run(path("fail.sh"))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, code)
+}
+
+func TestLocalExecutorOutputEncoding(t *testing.T) {
+	e := NewLocalExecutor(WithOutputEncoding(unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM)))
+	out, code, err := e.Run(path("utf16.sh"))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "hä€\n", out)
+}
+
+func TestLocalExecutorOutputEncodingBOM(t *testing.T) {
+	e := NewLocalExecutor(WithOutputEncoding(unicode.UTF8))
+	out, _, err := e.Run(path("utf16.sh"), "bom")
+	assert.NoError(t, err)
+	assert.Equal(t, "hä€\n", out)
+
+	out, _, err = NewLocalExecutor().Run(path("utf16.sh"), "bom")
+	assert.NoError(t, err)
+	assert.Equal(t, "\xff\xfeh\x00\xe4\x00\xac\x20\n\x00", out)
+}
+
+func TestLocalExecutorTrace(t *testing.T) {
+	var trace bytes.Buffer
+	e := NewLocalExecutor(WithTrace(&trace))
+
+	out, _, err := e.Run("echo", "foo bar", "it's")
+	assert.NoError(t, err)
+	assert.Equal(t, "foo bar it's\n", out)
+	_, _, err = e.RunLine(`printf '%s' "a b"`)
+	assert.NoError(t, err)
+	_, _, err = e.Run("unknown-command-that-does-not-exist", "x")
+	assert.True(t, errors.InstanceOf(err, ErrCommandNotFound))
+
+	assert.Equal(t, "+ echo "+Quote("foo bar")+" "+Quote("it's")+"\n+ printf %s "+Quote("a b")+"\n+ unknown-command-that-does-not-exist x\n", trace.String())
+}
+
+func TestLocalExecutorTracePipeline(t *testing.T) {
+	var trace bytes.Buffer
+	_, _, err := NewLocalExecutor(WithTrace(&trace)).RunPipeline(CommandSpec{Command: "echo", Args: []string{"a"}}, CommandSpec{Command: "cat"})
+	assert.NoError(t, err)
+	assert.Equal(t, "+ echo a\n+ cat\n", trace.String())
+}
+
+func TestLocalExecutorExtraFiles(t *testing.T) {
+	dir := t.TempDir()
+	out, err := os.Create(filepath.Join(dir, "out.txt"))
+	assert.NoError(t, err)
+	defer out.Close()
+	in, err := os.Open(path("static.json"))
+	assert.NoError(t, err)
+	defer in.Close()
+
+	e := NewLocalExecutor(WithExtraFiles(out), WithExtraFiles(in))
+	assert.Equal(t, 3, ExtraFileFD(0))
+	assert.Equal(t, 4, ExtraFileFD(1))
+	_, code, err := e.Run("sh", "-c", fmt.Sprintf("echo 'written to fd' >&%d", ExtraFileFD(0)))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "written to fd\n", readFile(t, out.Name()))
+
+	output, _, err := e.Run("sh", "-c", fmt.Sprintf("head -c 1 <&%d", ExtraFileFD(1)))
+	assert.NoError(t, err)
+	assert.Equal(t, "{", output)
+
+	// commands without extra files cannot access them
+	_, code, err = NewLocalExecutor().Run("sh", "-c", "echo test >&3")
+	assert.NoError(t, err)
+	assert.NotEqual(t, 0, code)
+}
+
+func TestLocalExecutorOutputFilter(t *testing.T) {
+	defer restoreDefaultExecutor(DefaultExecutor)
+	DefaultExecutor = NewLocalExecutor(
+		WithOutputFilter(func(output string) string { return strings.Replace(output, "secret", "******", -1) }),
+		WithOutputFilter(strings.ToUpper),
+		WithFatalOutputPatterns(regexp.MustCompile(`secret`)),
+	)
+
+	out, err := ShouldRun(path("args.sh"), "my secret", "token")
+	assert.NoError(t, err)
+	assert.Equal(t, "1MY ****** ; 2TOKEN\n", out)
+
+	_, lines, _, err := DefaultExecutor.(*LocalExecutor).RunOrdered(path("args.sh"), "secret")
+	assert.NoError(t, err)
+	assert.Equal(t, []OutputLine{{Source: SourceStdout, Line: "1****** ; 2"}}, lines)
+}
+
 /* ############################################# */
 /* ###                Helper                 ### */
 /* ############################################# */
@@ -290,3 +1277,7 @@ func TestMockExecutorRunLineParseFail(t *testing.T) {
 func path(cmd string) string {
 	return "./test/" + cmd
 }
+
+func restoreDefaultExecutor(e Executor) {
+	DefaultExecutor = e
+}
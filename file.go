@@ -0,0 +1,68 @@
+package exec
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/sbreitf1/errors"
+)
+
+var (
+	// ErrOutputFile occurs when the file for command output could not be created or written.
+	ErrOutputFile = errors.New("Could not write output file %q")
+)
+
+// RunToFile executes a command line with separated arguments and writes stdout directly to the file at path instead of capturing it in memory. The file is created or truncated. Stderr is discarded.
+func (e *LocalExecutor) RunToFile(path string, command string, args ...string) (int, errors.Error) {
+	return e.RunToFiles(path, "", command, args...)
+}
+
+// RunToFiles executes a command line with separated arguments and writes stdout to the file at stdoutPath and stderr to the file at stderrPath. Both streams are written to the same file if the paths are equal, and stderr is discarded if stderrPath is empty.
+func (e *LocalExecutor) RunToFiles(stdoutPath, stderrPath string, command string, args ...string) (int, errors.Error) {
+	stdout, err := createOutputFile(stdoutPath)
+	if err != nil {
+		return 0, err
+	}
+	defer stdout.Close()
+
+	var stderr io.Writer
+	if stderrPath == stdoutPath {
+		stderr = stdout
+	} else if len(stderrPath) > 0 {
+		f, err := createOutputFile(stderrPath)
+		if err != nil {
+			return 0, err
+		}
+		defer f.Close()
+		stderr = f
+	}
+
+	result := e.executeWith(context.Background(), nil, &outputBuffer{}, stdout, stderr, command, args...)
+	return result.ExitCode, result.Err
+}
+
+// RunToFile executes a command with given arguments using the DefaultExecutor and writes stdout to the file at path. Executors other than LocalExecutor capture the output in memory and write it to the file after completion.
+func RunToFile(path string, command string, args ...string) (int, errors.Error) {
+	if e, ok := DefaultExecutor.(*LocalExecutor); ok {
+		return e.RunToFile(path, command, args...)
+	}
+
+	output, code, err := DefaultExecutor.RunBytes(command, args...)
+	if err != nil {
+		return code, err
+	}
+	if err := ioutil.WriteFile(path, output, 0666); err != nil {
+		return code, ErrOutputFile.Args(path).Make().Cause(err)
+	}
+	return code, nil
+}
+
+func createOutputFile(path string) (*os.File, errors.Error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, ErrOutputFile.Args(path).Make().Cause(err)
+	}
+	return f, nil
+}
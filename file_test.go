@@ -0,0 +1,69 @@
+package exec
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/sbreitf1/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+/* ############################################# */
+/* ###               RunToFile               ### */
+/* ############################################# */
+
+func TestRunToFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "out.txt")
+	code, err := NewLocalExecutor().RunToFile(file, path("ordered.sh"))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, code)
+	assert.Equal(t, "out 1\nout 2\n", readFile(t, file))
+}
+
+func TestRunToFilesCombined(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "out.txt")
+	_, err := NewLocalExecutor().RunToFiles(file, file, path("ordered.sh"))
+	assert.NoError(t, err)
+	assert.Equal(t, "out 1\nerr 1\nout 2\nerr 2", readFile(t, file))
+}
+
+func TestRunToFilesSeparate(t *testing.T) {
+	dir := t.TempDir()
+	stdout, stderr := filepath.Join(dir, "out.txt"), filepath.Join(dir, "err.txt")
+	_, err := NewLocalExecutor().RunToFiles(stdout, stderr, path("ordered.sh"))
+	assert.NoError(t, err)
+	assert.Equal(t, "out 1\nout 2\n", readFile(t, stdout))
+	assert.Equal(t, "err 1\nerr 2", readFile(t, stderr))
+}
+
+func TestRunToFileCreateError(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "missing", "out.txt")
+	_, err := NewLocalExecutor().RunToFile(file, path("success.sh"))
+	assert.True(t, errors.InstanceOf(err, ErrOutputFile))
+}
+
+func TestRunToFileRunError(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "out.txt")
+	_, err := NewLocalExecutor().RunToFile(file, path("noexec.txt"))
+	assert.True(t, errors.InstanceOf(err, ErrPermissionDenied))
+}
+
+func TestRunToFileNonLocal(t *testing.T) {
+	defer restoreDefaultExecutor(DefaultExecutor)
+	DefaultExecutor = NewMockExecutor(func(command string, args ...string) (string, int, errors.Error) {
+		return "mocked output", 2, nil
+	})
+
+	file := filepath.Join(t.TempDir(), "out.txt")
+	code, err := RunToFile(file, "foo")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, code)
+	assert.Equal(t, "mocked output", readFile(t, file))
+}
+
+func readFile(t *testing.T, file string) string {
+	data, err := ioutil.ReadFile(file)
+	assert.NoError(t, err)
+	return string(data)
+}
@@ -0,0 +1,19 @@
+package exec
+
+import (
+	"strings"
+)
+
+// SplitFlag splits a parsed flag token like "--key=value" at the first equals sign into "--key" and "value". Quoting has already been removed by the parser, so `--key="a b"` arrives as "--key=a b" and yields the value "a b". Flags without a value like "--key" are returned as key with hasValue set to false, allowing to read the value from the next token instead. Tokens that are no flags, including "-" and "--", return an empty key.
+func SplitFlag(token string) (key string, value string, hasValue bool) {
+	name := strings.TrimLeft(token, "-")
+	if len(name) == len(token) || len(token)-len(name) > 2 || len(name) == 0 || name[0] == '=' {
+		return "", "", false
+	}
+
+	i := strings.IndexByte(token, '=')
+	if i < 0 {
+		return token, "", false
+	}
+	return token[:i], token[i+1:], true
+}
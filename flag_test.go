@@ -0,0 +1,48 @@
+package exec
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+/* ############################################# */
+/* ###               SplitFlag               ### */
+/* ############################################# */
+
+func TestSplitFlag(t *testing.T) {
+	assertSplitFlag(t, "--key=value", "--key", "value", true)
+	assertSplitFlag(t, "-k=value", "-k", "value", true)
+	assertSplitFlag(t, "--key", "--key", "", false)
+	assertSplitFlag(t, "--key=", "--key", "", true)
+	assertSplitFlag(t, "--key=a=b", "--key", "a=b", true)
+	assertSplitFlag(t, "--key==", "--key", "=", true)
+}
+
+func TestSplitFlagNoFlag(t *testing.T) {
+	assertSplitFlag(t, "value", "", "", false)
+	assertSplitFlag(t, "a=b", "", "", false)
+	assertSplitFlag(t, "", "", "", false)
+	assertSplitFlag(t, "-", "", "", false)
+	assertSplitFlag(t, "--", "", "", false)
+	assertSplitFlag(t, "---key=value", "", "", false)
+	assertSplitFlag(t, "--=value", "", "", false)
+}
+
+func TestSplitFlagParsed(t *testing.T) {
+	args, err := Fields(`--key="a b" --name='x=y z' --path=foo\ bar --flag`)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, len(args))
+
+	assertSplitFlag(t, args[0], "--key", "a b", true)
+	assertSplitFlag(t, args[1], "--name", "x=y z", true)
+	assertSplitFlag(t, args[2], "--path", "foo bar", true)
+	assertSplitFlag(t, args[3], "--flag", "", false)
+}
+
+func assertSplitFlag(t *testing.T, token, expectedKey, expectedValue string, expectedHasValue bool) {
+	key, value, hasValue := SplitFlag(token)
+	assert.Equal(t, expectedKey, key, "key of %q", token)
+	assert.Equal(t, expectedValue, value, "value of %q", token)
+	assert.Equal(t, expectedHasValue, hasValue, "hasValue of %q", token)
+}
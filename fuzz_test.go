@@ -0,0 +1,124 @@
+//go:build go1.18
+// +build go1.18
+
+package exec
+
+import (
+	"testing"
+	"unicode/utf8"
+)
+
+/* ############################################# */
+/* ###                 Fuzz                  ### */
+/* ############################################# */
+
+func FuzzParse(f *testing.F) {
+	for _, seed := range []string{
+		``,
+		`a`,
+		`\`,
+		`'`,
+		`"`,
+		`$'`,
+		`$'\`,
+		`$'\x`,
+		`$'\u12`,
+		`$'\c`,
+		`$'\777'`,
+		`a\`,
+		"a\\\n",
+		"a\\\r",
+		"a\000b",
+		`"a\"b" 'c'"d"$'e\n'`,
+		`foo "bar baz" 'qux' \ x`,
+		"ä ö ü \U0001F600",
+		"\xff\xfe",
+	} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, commandLine string) {
+		parts, err := split(commandLine, DefaultParseConfig())
+		if err != nil {
+			return
+		}
+		if len(parts) == 0 {
+			return
+		}
+		for _, part := range parts {
+			if !utf8.ValidString(part) {
+				// invalid byte sequences cannot be represented by GetCommandLine
+				return
+			}
+		}
+
+		// the quoted representation must always be parsed to the same tokens
+		reparsed, err := split(GetCommandLine(parts[0], parts[1:]...), DefaultParseConfig())
+		if err != nil {
+			t.Fatalf("quoted command line of %q cannot be parsed: %v", commandLine, err)
+		}
+		if len(reparsed) != len(parts) {
+			t.Fatalf("quoted command line of %q yields %d instead of %d tokens", commandLine, len(reparsed), len(parts))
+		}
+		for i := range parts {
+			if parts[i] != reparsed[i] {
+				t.Fatalf("token %d of %q changed from %q to %q after quoting", i, commandLine, parts[i], reparsed[i])
+			}
+		}
+	})
+}
+
+func FuzzParseWith(f *testing.F) {
+	f.Add(`a "b c" 'd' \e`, '\'', '"', '\\', false)
+	f.Add(`a |b c| %d`, '|', '#', '%', false)
+	f.Add("a\\\nb", '\'', '"', '\\', true)
+	f.Add(`$'\x41'`, '\'', '"', '\\', false)
+
+	f.Fuzz(func(t *testing.T, commandLine string, singleQuote, doubleQuote, escape rune, disableEscape bool) {
+		// only the absence of panics is checked for arbitrary configurations
+		ParseWith(commandLine, ParseConfig{SingleQuote: singleQuote, DoubleQuote: doubleQuote, Escape: escape, DisableEscape: disableEscape})
+	})
+}
+
+func FuzzParsePipeline(f *testing.F) {
+	f.Add(`cat < in.txt | grep -v "foo bar" | sort > out.txt 2> err.txt`)
+	f.Add(`a|b>>log 2>>errlog<in`)
+	f.Add(`a 2`)
+	f.Add(`|`)
+
+	f.Fuzz(func(t *testing.T, commandLine string) {
+		ParsePipeline(commandLine)
+	})
+}
+
+func FuzzParseRaw(f *testing.F) {
+	f.Add(`git commit -m "fix: it's done" --author='A B' foo\ bar $'a\tb' x"y"'z'`)
+	f.Add("echo foo\\\nbar \\\n baz")
+	f.Add("ä \"ö\" \xff")
+
+	f.Fuzz(func(t *testing.T, commandLine string) {
+		tokens, err := ParseRaw(commandLine)
+		if err != nil {
+			return
+		}
+
+		// the raw tokens must be located in the command line and yield the same values when joined
+		for _, token := range tokens {
+			if commandLine[token.Offset:token.Offset+len(token.Raw)] != token.Raw {
+				t.Fatalf("raw token %q is not located at offset %d of %q", token.Raw, token.Offset, commandLine)
+			}
+		}
+		reparsed, err := ParseRaw(JoinRawTokens(tokens))
+		if err != nil {
+			t.Fatalf("joined raw tokens of %q cannot be parsed: %v", commandLine, err)
+		}
+		if len(reparsed) != len(tokens) {
+			t.Fatalf("joined raw tokens of %q yield %d instead of %d tokens", commandLine, len(reparsed), len(tokens))
+		}
+		for i := range tokens {
+			if tokens[i].Value != reparsed[i].Value {
+				t.Fatalf("token %d of %q changed from %q to %q after joining", i, commandLine, tokens[i].Value, reparsed[i].Value)
+			}
+		}
+	})
+}
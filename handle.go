@@ -0,0 +1,318 @@
+package exec
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/sbreitf1/errors"
+)
+
+// RunHandle represents a command that has been started asynchronously.
+type RunHandle struct {
+	executor *LocalExecutor
+	cmd      *exec.Cmd
+	output   *outputBuffer
+	done     chan struct{}
+	// exited is closed as soon as the process has been reaped, which might be before all output has been collected
+	exited chan struct{}
+	// activity receives a value whenever the process writes output, nil if not required
+	activity chan struct{}
+	// drained is closed when all output has been collected, nil if the output is complete as soon as the process exited
+	drained <-chan struct{}
+	// abort stops collecting output, so drained is closed even if child processes still hold the output open, nil if not required
+	abort func()
+	code  int
+	err   errors.Error
+	// signal denotes the signal that terminated the process, nil if it exited on its own
+	signal os.Signal
+	// escalated is set when the process had to be killed after ignoring the graceful shutdown signal
+	escalated bool
+}
+
+// Start executes a command with given arguments asynchronously and returns a handle to control the running process. The process is reaped as soon as it exits, even if the handle is never waited for, so abandoned handles do not leave defunct processes behind.
+func (e *LocalExecutor) Start(command string, args ...string) (*RunHandle, errors.Error) {
+	return e.start(nil, command, args...)
+}
+
+// start launches the command and additionally forwards all output to stream if not nil.
+func (e *LocalExecutor) start(stream io.Writer, command string, args ...string) (*RunHandle, errors.Error) {
+	output := e.newOutputBuffer(stream)
+	return e.launch(nil, output, output, output, command, args...)
+}
+
+// launch starts the command with stdin, stdout and stderr connected to the given streams, and reports the data forwarded to output by the writers as process output. A nil stdin reads from the null device.
+func (e *LocalExecutor) launch(stdin io.Reader, output *outputBuffer, stdout, stderr io.Writer, command string, args ...string) (*RunHandle, errors.Error) {
+	var activity chan struct{}
+	if e.idleTimeout > 0 {
+		activity = make(chan struct{}, 1)
+		stdout = newActivityWriter(stdout, activity)
+		stderr = newActivityWriter(stderr, activity)
+	}
+
+	cmd, err := e.command(stdin, stdout, stderr, command, args...)
+	if err != nil {
+		return nil, err
+	}
+	return e.startCommand(cmd, output, activity, nil, nil)
+}
+
+// command prepares the command according to the executor settings without starting it.
+func (e *LocalExecutor) command(stdin io.Reader, stdout, stderr io.Writer, command string, args ...string) (*exec.Cmd, errors.Error) {
+	path := command
+	if e.cleanEnv {
+		resolved, err := e.lookPath(command)
+		if err != nil {
+			return nil, startError(command, err)
+		}
+		path = resolved
+	}
+	cmd := exec.Command(path, args...)
+	cmd.Args[0] = command
+	cmd.Dir = e.dir
+	cmd.Env = e.environment()
+	cmd.Stdin = stdin
+	if e.processGroup {
+		setProcessGroup(cmd)
+	}
+	if e.credential != nil {
+		if err := e.credential.apply(cmd); err != nil {
+			return nil, err
+		}
+	}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	if len(e.extraFiles) > 0 {
+		cmd.ExtraFiles = append([]*os.File{}, e.extraFiles...)
+	}
+	for _, configure := range e.configurators {
+		configure(cmd)
+	}
+	return cmd, nil
+}
+
+// startCommand starts a prepared command and returns a handle that reports the data written to output as process output. Activity denotes the channel notified about output for the idle timeout, and the handle is not done before drained is closed if not nil. Abort is called to stop collecting output of a process that has been stopped.
+//
+// If drained is nil, output written to writers other than files is collected using pipes owned by the handle, see outputPipes.
+func (e *LocalExecutor) startCommand(cmd *exec.Cmd, output *outputBuffer, activity chan struct{}, drained <-chan struct{}, abort func()) (*RunHandle, errors.Error) {
+	command := cmd.Args[0]
+	if e.trace != nil {
+		io.WriteString(e.trace, "+ "+GetCommandLine(command, cmd.Args[1:]...)+"\n")
+	}
+
+	var pipes *outputPipes
+	if drained == nil {
+		var err error
+		if pipes, err = newOutputPipes(cmd); err != nil {
+			return nil, ErrRun.Make().Cause(err)
+		}
+	}
+	if err := cmd.Start(); err != nil {
+		if pipes != nil {
+			pipes.close()
+		}
+		if e.credential != nil && isNotPermitted(err) {
+			return nil, e.credential.error().Cause(err)
+		}
+		return nil, startError(command, err)
+	}
+	if pipes != nil {
+		drained, abort = pipes.collect(), pipes.abort
+	}
+	if e.niceness != nil {
+		if err := setNiceness(cmd.Process.Pid, *e.niceness); err != nil {
+			// the process has already been started and must be reaped before returning
+			cmd.Process.Kill()
+			cmd.Wait()
+			if abort != nil {
+				abort()
+			}
+			return nil, ErrNiceness.Args(*e.niceness).Make().Cause(err)
+		}
+	}
+
+	h := &RunHandle{
+		executor: e,
+		cmd:      cmd,
+		output:   output,
+		done:     make(chan struct{}),
+		exited:   make(chan struct{}),
+		activity: activity,
+		drained:  drained,
+		abort:    abort,
+	}
+	go h.wait()
+	return h, nil
+}
+
+// wait reaps the process and must be started for every process that has been started successfully. All other ways of waiting for the process only wait for exited or done.
+func (h *RunHandle) wait() {
+	h.code, h.signal, h.err = exitStatus(h.cmd.Wait())
+	close(h.exited)
+	if h.drained != nil {
+		<-h.drained
+	}
+	close(h.done)
+}
+
+// Output returns the combined output the process has written so far.
+func (h *RunHandle) Output() string {
+	return h.output.String()
+}
+
+// Done returns a channel that is closed as soon as the process has exited and all output has been collected. Child processes that inherited stdout or stderr delay Done until they exit or close the output as well, see Exited.
+func (h *RunHandle) Done() <-chan struct{} {
+	return h.done
+}
+
+// Exited returns a channel that is closed as soon as the process has exited, regardless of child processes that still write output.
+func (h *RunHandle) Exited() <-chan struct{} {
+	return h.exited
+}
+
+// Signal sends sig to the running process. The signal is sent to the whole process group if the executor has been configured using WithProcessGroup.
+func (h *RunHandle) Signal(sig os.Signal) errors.Error {
+	var err error
+	if h.executor.processGroup {
+		err = signalProcessGroup(h.cmd.Process, sig)
+	} else {
+		err = h.cmd.Process.Signal(sig)
+	}
+	if err != nil {
+		return ErrSignal.Make().Cause(err)
+	}
+	return nil
+}
+
+// Kill forcefully terminates the running process.
+func (h *RunHandle) Kill() errors.Error {
+	return h.Signal(os.Kill)
+}
+
+// Wait blocks until Done is closed and returns the output and exit code of the process.
+func (h *RunHandle) Wait() (string, int, errors.Error) {
+	<-h.done
+	return h.output.String(), h.code, h.err
+}
+
+// WaitContext behaves like Wait, but stops the process when ctx is done. ErrTimeout is returned in this case if the deadline of ctx has been exceeded and ErrCanceled otherwise.
+func (h *RunHandle) WaitContext(ctx context.Context) (string, int, errors.Error) {
+	select {
+	case <-h.done:
+		return h.Wait()
+	case <-ctx.Done():
+	}
+
+	h.shutdown()
+	// children of the stopped process might still hold the output open and must not delay the result
+	<-h.exited
+	if h.abort != nil {
+		h.abort()
+	}
+	output, code, _ := h.Wait()
+	return output, code, contextError(ctx.Err())
+}
+
+// shutdown stops the process according to the shutdown settings of the executor.
+func (h *RunHandle) shutdown() {
+	if h.executor.shutdownSignal != nil {
+		if err := h.Signal(h.executor.shutdownSignal); err == nil {
+			select {
+			case <-h.exited:
+				return
+			case <-time.After(h.executor.shutdownGrace):
+				h.escalated = true
+			}
+		}
+	}
+	h.Kill()
+}
+
+// newOutputBuffer returns a buffer for process output according to the output limits of the executor.
+func (e *LocalExecutor) newOutputBuffer(stream io.Writer) *outputBuffer {
+	b := &outputBuffer{stream: stream, max: e.maxOutput, tail: e.tailOutput}
+	if hint := e.outputHint; hint > 0 {
+		if b.max > 0 && b.max < hint {
+			hint = b.max
+		}
+		if b.tail > 0 && b.tail < hint {
+			hint = b.tail
+		}
+		b.buffer.Grow(hint)
+	}
+	return b
+}
+
+// outputBuffer collects process output and can safely be read while the process is still running.
+type outputBuffer struct {
+	mutex  sync.Mutex
+	buffer bytes.Buffer
+	stream io.Writer
+	// max denotes the maximum number of leading bytes to keep, zero for unlimited
+	max int
+	// tail denotes the maximum number of trailing bytes to keep, zero for unlimited
+	tail  int
+	total int
+	// discarding is set when further output is only counted, but not kept anymore
+	discarding bool
+}
+
+func (b *outputBuffer) Write(p []byte) (int, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if b.stream != nil {
+		// a failing stream must not abort the command
+		b.stream.Write(p)
+	}
+
+	b.total += len(p)
+	if b.discarding {
+		return len(p), nil
+	}
+	keep := p
+	if b.max > 0 {
+		if remaining := b.max - b.buffer.Len(); remaining < len(keep) {
+			keep = keep[:remaining]
+		}
+	}
+	if b.tail > 0 && len(keep) > b.tail {
+		keep = keep[len(keep)-b.tail:]
+	}
+	b.buffer.Write(keep)
+	if b.tail > 0 && b.buffer.Len() > b.tail {
+		// discard the oldest bytes, the buffer compacts itself on further writes
+		b.buffer.Next(b.buffer.Len() - b.tail)
+	}
+	return len(p), nil
+}
+
+// discard stops keeping further output, which is still forwarded to the stream and counted.
+func (b *outputBuffer) discard() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.discarding = true
+}
+
+// Total returns the number of bytes written to the buffer, including discarded bytes.
+func (b *outputBuffer) Total() int {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.total
+}
+
+// Truncated returns true if any output has been discarded due to the output limits.
+func (b *outputBuffer) Truncated() bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.total > b.buffer.Len()
+}
+
+func (b *outputBuffer) String() string {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.buffer.String()
+}
@@ -0,0 +1,208 @@
+package exec
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/sbreitf1/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+/* ############################################# */
+/* ###               RunHandle               ### */
+/* ############################################# */
+
+func TestStartWait(t *testing.T) {
+	h, err := NewLocalExecutor().Start(path("success.sh"))
+	assert.NoError(t, err)
+	out, code, err := h.Wait()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, code)
+	assert.True(t, strings.Contains(out, "some test output here"))
+}
+
+func TestStartError(t *testing.T) {
+	_, err := NewLocalExecutor().Start(path("noexec.txt"))
+	assert.True(t, errors.InstanceOf(err, ErrPermissionDenied))
+}
+
+func TestRunHandleSignal(t *testing.T) {
+	h, err := NewLocalExecutor().Start(path("signal.sh"))
+	assert.NoError(t, err)
+	waitForOutput(t, h, "ready")
+
+	assert.NoError(t, h.Signal(syscall.SIGHUP))
+	out, code, err := h.Wait()
+	assert.NoError(t, err)
+	assert.Equal(t, 3, code)
+	assert.True(t, strings.Contains(out, "caught signal"))
+}
+
+func TestRunHandleSignalExited(t *testing.T) {
+	h, err := NewLocalExecutor().Start(path("success.sh"))
+	assert.NoError(t, err)
+	h.Wait()
+
+	assert.True(t, errors.InstanceOf(h.Signal(syscall.SIGHUP), ErrSignal))
+}
+
+func TestStartExited(t *testing.T) {
+	// the background child inherits the output and keeps it open after the shell exited
+	h, err := NewLocalExecutor().Start("sh", "-c", "sleep 1 & echo started")
+	assert.NoError(t, err)
+
+	select {
+	case <-h.Exited():
+	case <-time.After(800 * time.Millisecond):
+		assert.Fail(t, "Exited has not been closed")
+	}
+	select {
+	case <-h.Done():
+		assert.Fail(t, "Done must wait for the output to be collected")
+	default:
+	}
+
+	out, code, err := h.Wait()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "started\n", out)
+}
+
+func TestRunContextCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	time.AfterFunc(200*time.Millisecond, cancel)
+
+	out, _, err := NewLocalExecutor().RunContext(ctx, path("signal.sh"))
+	assert.True(t, errors.InstanceOf(err, ErrCanceled))
+	assert.Contains(t, err.Error(), context.Canceled.Error())
+	assert.False(t, strings.Contains(out, "caught signal"))
+}
+
+func TestRunContextAlreadyDone(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "started")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	for i := 0; i < 20; i++ {
+		_, _, err := NewLocalExecutor().RunContext(ctx, "touch", file)
+		assert.True(t, errors.InstanceOf(err, ErrCanceled))
+	}
+	_, err := os.Stat(file)
+	assert.True(t, os.IsNotExist(err))
+
+	ctx, cancel = context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	_, _, err = NewLocalExecutor().RunContext(ctx, "touch", file)
+	assert.True(t, errors.InstanceOf(err, ErrTimeout))
+	_, err = os.Stat(file)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestRunContextDeadline(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	out, _, err := NewLocalExecutor().RunContext(ctx, path("signal.sh"))
+	assert.True(t, errors.InstanceOf(err, ErrTimeout))
+	assert.Contains(t, err.Error(), context.DeadlineExceeded.Error())
+	assert.Equal(t, KindTimeout, ErrorKind(err))
+	assert.False(t, strings.Contains(out, "caught signal"))
+}
+
+func TestRunContextGracefulShutdown(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	e := NewLocalExecutor(WithGracefulShutdown(os.Interrupt, 5*time.Second))
+	out, code, err := e.RunContext(ctx, path("signal.sh"))
+	assert.True(t, errors.InstanceOf(err, ErrTimeout))
+	assert.Equal(t, 3, code)
+	assert.True(t, strings.Contains(out, "caught signal"))
+}
+
+func TestRunLineContextParseError(t *testing.T) {
+	_, _, err := NewLocalExecutor().RunLineContext(context.Background(), `newcommand "test`)
+	assert.True(t, errors.InstanceOf(err, ErrParse))
+}
+
+func TestRunTimeout(t *testing.T) {
+	e := NewLocalExecutor(WithTimeout(200 * time.Millisecond))
+	_, _, err := e.Run(path("signal.sh"))
+	assert.True(t, errors.InstanceOf(err, ErrTimeout))
+}
+
+func TestRunTimeoutNotReached(t *testing.T) {
+	e := NewLocalExecutor(WithTimeout(5 * time.Second))
+	out, code, err := e.Run(path("success.sh"))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, code)
+	assert.True(t, strings.Contains(out, "some test output here"))
+}
+
+func TestRunTimeoutChildHoldsOutput(t *testing.T) {
+	// the background child inherits the output pipe and keeps it open after the shell has been killed
+	start := time.Now()
+	e := NewLocalExecutor(WithTimeout(200 * time.Millisecond))
+	out, _, err := e.Run("sh", "-c", "sleep 3 & echo started; sleep 5")
+	assert.True(t, errors.InstanceOf(err, ErrTimeout))
+	assert.Equal(t, "started\n", out)
+	assert.True(t, time.Since(start) < 2*time.Second)
+}
+
+func TestRunIdleTimeout(t *testing.T) {
+	start := time.Now()
+	e := NewLocalExecutor(WithIdleTimeout(300*time.Millisecond), WithProcessGroup())
+	out, _, err := e.Run(path("idle.sh"), "hang")
+	assert.True(t, errors.InstanceOf(err, ErrIdleTimeout))
+	assert.Equal(t, KindTimeout, ErrorKind(err))
+	assert.True(t, strings.Contains(out, "tick 5"))
+	assert.True(t, time.Since(start) < 10*time.Second)
+}
+
+func TestRunIdleTimeoutChildHoldsOutput(t *testing.T) {
+	// the background child inherits the output pipe and keeps it open after the shell has been killed
+	start := time.Now()
+	e := NewLocalExecutor(WithIdleTimeout(200 * time.Millisecond))
+	out, _, err := e.Run("sh", "-c", "sleep 3 & echo started; sleep 5")
+	assert.True(t, errors.InstanceOf(err, ErrIdleTimeout))
+	assert.Equal(t, "started\n", out)
+	assert.True(t, time.Since(start) < 2*time.Second)
+}
+
+func TestRunIdleTimeoutActive(t *testing.T) {
+	// the total runtime exceeds the idle timeout, but output is written continuously
+	e := NewLocalExecutor(WithIdleTimeout(300 * time.Millisecond))
+	out, code, err := e.Run(path("idle.sh"))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, code)
+	assert.True(t, strings.Contains(out, "tick 5"))
+}
+
+func TestRunContextProcessGroup(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	e := NewLocalExecutor(WithProcessGroup())
+	out, _, err := e.RunContext(ctx, path("group.sh"))
+	assert.True(t, errors.InstanceOf(err, ErrTimeout))
+	assert.True(t, strings.Contains(out, "ready"))
+	// the orphaned sleep would keep the output pipe open for 30 seconds
+	assert.True(t, time.Since(start) < 10*time.Second)
+}
+
+func waitForOutput(t *testing.T, h *RunHandle, str string) {
+	deadline := time.Now().Add(5 * time.Second)
+	for !strings.Contains(h.Output(), str) {
+		if time.Now().After(deadline) {
+			assert.FailNow(t, "Expected output did not appear in time")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
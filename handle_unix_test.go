@@ -0,0 +1,112 @@
+//go:build !windows
+// +build !windows
+
+package exec
+
+import (
+	"context"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/sbreitf1/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+/* ############################################# */
+/* ###          Signal Termination           ### */
+/* ############################################# */
+
+func TestRunSignalExitCode(t *testing.T) {
+	out, code, err := NewLocalExecutor().Run("sh", "-c", "echo before; kill -TERM $$; echo after")
+	assert.NoError(t, err)
+	assert.Equal(t, 128+int(syscall.SIGTERM), code)
+	assert.Equal(t, "before\n", out)
+
+	result := NewLocalExecutor().Execute(context.Background(), "sh", "-c", "kill -KILL $$")
+	assert.NoError(t, result.Err)
+	assert.Equal(t, 137, result.ExitCode)
+	assert.Equal(t, syscall.SIGKILL, result.Signal)
+
+	result = NewLocalExecutor().Execute(context.Background(), "sh", "-c", "exit 3")
+	assert.Equal(t, 3, result.ExitCode)
+	assert.Nil(t, result.Signal)
+}
+
+func TestRunHandleSignalExitCode(t *testing.T) {
+	h, err := NewLocalExecutor().Start("sleep", "30")
+	assert.NoError(t, err)
+	assert.NoError(t, h.Signal(syscall.SIGINT))
+	_, code, err := h.Wait()
+	assert.NoError(t, err)
+	assert.Equal(t, 130, code)
+}
+
+/* ############################################# */
+/* ###          Shutdown Escalation          ### */
+/* ############################################# */
+
+func TestEscalatedToKill(t *testing.T) {
+	e := NewLocalExecutor(WithTimeout(200*time.Millisecond), WithGracefulShutdown(syscall.SIGTERM, 200*time.Millisecond))
+	result := e.Execute(context.Background(), path("ignoreterm.sh"))
+	assert.True(t, errors.InstanceOf(result.Err, ErrTimeout))
+	assert.True(t, result.EscalatedToKill)
+}
+
+func TestNotEscalatedToKill(t *testing.T) {
+	e := NewLocalExecutor(WithTimeout(200*time.Millisecond), WithGracefulShutdown(syscall.SIGTERM, 5*time.Second))
+	result := e.Execute(context.Background(), path("signal.sh"))
+	assert.True(t, errors.InstanceOf(result.Err, ErrTimeout))
+	assert.Equal(t, 3, result.ExitCode)
+	assert.False(t, result.EscalatedToKill)
+}
+
+/* ############################################# */
+/* ###              Credentials              ### */
+/* ############################################# */
+
+func TestWithCredential(t *testing.T) {
+	e := NewLocalExecutor(WithCredential(65534, 65534, 65534))
+	out, code, err := e.Run("id")
+	if os.Geteuid() != 0 {
+		assert.True(t, errors.InstanceOf(err, ErrCredential))
+		return
+	}
+
+	assert.NoError(t, err)
+	assert.Equal(t, 0, code)
+	assert.True(t, strings.HasPrefix(out, "uid=65534"))
+	assert.True(t, strings.Contains(out, "gid=65534"))
+}
+
+func TestWithCredentialInvalid(t *testing.T) {
+	e := NewLocalExecutor(WithCredential(^uint32(0), 0))
+	_, _, err := e.Run("id")
+	assert.True(t, errors.InstanceOf(err, ErrCredential))
+	assert.Equal(t, KindRun, ErrorKind(err))
+
+	_, err = NewLocalExecutor(WithCredential(0, 0, ^uint32(0))).Start("id")
+	assert.True(t, errors.InstanceOf(err, ErrCredential))
+}
+
+/* ############################################# */
+/* ###               Niceness                ### */
+/* ############################################# */
+
+func TestWithNiceness(t *testing.T) {
+	out, code, err := NewLocalExecutor(WithNiceness(10)).Run(path("nice.sh"))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "10", strings.TrimSpace(out))
+}
+
+func TestWithNicenessPrivileged(t *testing.T) {
+	_, _, err := NewLocalExecutor(WithNiceness(-5)).Run(path("nice.sh"))
+	if os.Geteuid() == 0 {
+		assert.NoError(t, err)
+	} else {
+		assert.True(t, errors.InstanceOf(err, ErrNiceness))
+	}
+}
@@ -0,0 +1,60 @@
+package exec
+
+import (
+	"sync"
+	"time"
+)
+
+var (
+	// OnRun is called after every command executed by a LocalExecutor, including commands that returned a non-zero exit code or could not be started. Command lines passed to RunLine are reported after parsing. Panics in OnRun are recovered and do not affect the result of the command.
+	OnRun func(command string, args []string, result RunResult)
+
+	metricsMutex     sync.RWMutex
+	metricsCollector MetricsCollector
+)
+
+// MetricsCollector receives observations about all commands executed by a LocalExecutor. This allows to record counters and duration histograms without wrapping every call.
+type MetricsCollector interface {
+	// ObserveRun is called after every command with its exit code, the execution duration and the error, which is nil for commands that have been executed successfully regardless of the exit code.
+	ObserveRun(command string, code int, dur time.Duration, err error)
+}
+
+// SetMetricsCollector registers c to observe all commands executed by a LocalExecutor. Use nil to remove the current collector. Panics in the collector are recovered and do not affect the result of the command.
+func SetMetricsCollector(c MetricsCollector) {
+	metricsMutex.Lock()
+	defer metricsMutex.Unlock()
+	metricsCollector = c
+}
+
+// notifyRun passes the result to OnRun and the registered MetricsCollector if set.
+func notifyRun(command string, args []string, result RunResult) {
+	metricsMutex.RLock()
+	collector := metricsCollector
+	metricsMutex.RUnlock()
+	if collector != nil {
+		observeRun(collector, command, result)
+	}
+
+	hook := OnRun
+	if hook == nil {
+		return
+	}
+
+	defer func() {
+		recover()
+	}()
+	hook(command, args, result)
+}
+
+func observeRun(collector MetricsCollector, command string, result RunResult) {
+	defer func() {
+		recover()
+	}()
+
+	// avoid passing a typed nil as error interface
+	var err error
+	if result.Err != nil {
+		err = result.Err
+	}
+	collector.ObserveRun(command, result.ExitCode, result.Duration, err)
+}
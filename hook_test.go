@@ -0,0 +1,112 @@
+package exec
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sbreitf1/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+/* ############################################# */
+/* ###                 OnRun                 ### */
+/* ############################################# */
+
+func TestOnRun(t *testing.T) {
+	defer restoreOnRun(OnRun)
+	var lastCommand string
+	var lastArgs []string
+	var lastResult RunResult
+	OnRun = func(command string, args []string, result RunResult) {
+		lastCommand = command
+		lastArgs = args
+		lastResult = result
+	}
+
+	Run(path("args.sh"), "foo", "bar")
+	assert.Equal(t, path("args.sh"), lastCommand)
+	assert.Equal(t, []string{"foo", "bar"}, lastArgs)
+	assert.NoError(t, lastResult.Err)
+	assert.Equal(t, 0, lastResult.ExitCode)
+	assert.True(t, strings.Contains(lastResult.Output, "1foo ; 2bar"))
+	assert.True(t, lastResult.Duration > 0)
+
+	RunLine(Quote(path("fail.sh")) + " blub")
+	assert.Equal(t, path("fail.sh"), lastCommand)
+	assert.Equal(t, []string{"blub"}, lastArgs)
+	assert.Equal(t, 1, lastResult.ExitCode)
+
+	Run(path("noexec.txt"))
+	assert.Equal(t, path("noexec.txt"), lastCommand)
+	assert.True(t, errors.InstanceOf(lastResult.Err, ErrPermissionDenied))
+}
+
+func TestOnRunPanic(t *testing.T) {
+	defer restoreOnRun(OnRun)
+	OnRun = func(command string, args []string, result RunResult) {
+		panic("broken hook")
+	}
+
+	out, code, err := Run(path("success.sh"))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, code)
+	assert.True(t, strings.Contains(out, "some test output here"))
+}
+
+/* ############################################# */
+/* ###           MetricsCollector            ### */
+/* ############################################# */
+
+type testCollector struct {
+	commands []string
+	codes    []int
+	errs     []error
+	total    time.Duration
+}
+
+func (c *testCollector) ObserveRun(command string, code int, dur time.Duration, err error) {
+	c.commands = append(c.commands, command)
+	c.codes = append(c.codes, code)
+	c.errs = append(c.errs, err)
+	c.total += dur
+}
+
+func TestMetricsCollector(t *testing.T) {
+	defer SetMetricsCollector(nil)
+	c := &testCollector{}
+	SetMetricsCollector(c)
+
+	Run(path("success.sh"))
+	Run(path("fail.sh"))
+	Run(path("noexec.txt"))
+	SetMetricsCollector(nil)
+	Run(path("success.sh"))
+
+	assert.Equal(t, []string{path("success.sh"), path("fail.sh"), path("noexec.txt")}, c.commands)
+	assert.Equal(t, []int{0, 1, 0}, c.codes)
+	assert.Nil(t, c.errs[0])
+	assert.Nil(t, c.errs[1])
+	assert.True(t, errors.InstanceOf(c.errs[2], ErrPermissionDenied))
+	assert.True(t, c.total > 0)
+}
+
+type panicCollector struct{}
+
+func (panicCollector) ObserveRun(command string, code int, dur time.Duration, err error) {
+	panic("collector failure")
+}
+
+func TestMetricsCollectorPanic(t *testing.T) {
+	defer SetMetricsCollector(nil)
+	SetMetricsCollector(panicCollector{})
+
+	out, code, err := Run(path("success.sh"))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, code)
+	assert.True(t, strings.Contains(out, "some test output here"))
+}
+
+func restoreOnRun(hook func(command string, args []string, result RunResult)) {
+	OnRun = hook
+}
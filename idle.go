@@ -0,0 +1,58 @@
+package exec
+
+import (
+	"context"
+	"io"
+	"sync/atomic"
+	"time"
+)
+
+// watchIdle returns a context that is cancelled when the process of h did not write any output for the idle timeout of the executor. The returned functions release the watcher and report whether the idle timeout has been reached.
+func (e *LocalExecutor) watchIdle(ctx context.Context, h *RunHandle) (context.Context, func(), func() bool) {
+	idleCtx, cancel := context.WithCancel(ctx)
+	var idle int32
+
+	go func() {
+		timer := time.NewTimer(e.idleTimeout)
+		defer timer.Stop()
+		for {
+			select {
+			case <-h.activity:
+				if !timer.Stop() {
+					<-timer.C
+				}
+				timer.Reset(e.idleTimeout)
+			case <-timer.C:
+				atomic.StoreInt32(&idle, 1)
+				cancel()
+				return
+			case <-idleCtx.Done():
+				return
+			}
+		}
+	}()
+
+	return idleCtx, cancel, func() bool { return atomic.LoadInt32(&idle) == 1 }
+}
+
+// activityWriter forwards all data to an inner writer and signals every write on a channel without blocking.
+type activityWriter struct {
+	w        io.Writer
+	activity chan<- struct{}
+}
+
+// newActivityWriter returns w wrapped by an activityWriter or nil if w is nil.
+func newActivityWriter(w io.Writer, activity chan<- struct{}) io.Writer {
+	if w == nil {
+		return nil
+	}
+	return &activityWriter{w, activity}
+}
+
+func (w *activityWriter) Write(p []byte) (int, error) {
+	select {
+	case w.activity <- struct{}{}:
+	default:
+	}
+	return w.w.Write(p)
+}
@@ -0,0 +1,27 @@
+package exec
+
+import (
+	"context"
+	"os"
+
+	"github.com/sbreitf1/errors"
+)
+
+// RunInteractive executes a command line with separated arguments connected to stdin, stdout and stderr of the current process, so it can prompt the user or take over the terminal like password prompts and editors do. No output is captured in this mode and only the exit code is returned. The idle timeout of the executor is ignored because output is not observed.
+func (e *LocalExecutor) RunInteractive(command string, args ...string) (int, errors.Error) {
+	// the standard streams must be passed as files to keep the terminal attached to the process
+	interactive := e.With(WithIdleTimeout(0))
+	result := interactive.executeWith(context.Background(), os.Stdin, &outputBuffer{}, os.Stdout, os.Stderr, command, args...)
+	return result.ExitCode, result.Err
+}
+
+// RunInteractive executes a command with given arguments using the DefaultExecutor connected to the standard streams of the current process. Executors other than LocalExecutor cannot read from stdin and write the output to stdout after completion.
+func RunInteractive(command string, args ...string) (int, errors.Error) {
+	if e, ok := DefaultExecutor.(*LocalExecutor); ok {
+		return e.RunInteractive(command, args...)
+	}
+
+	output, code, err := DefaultExecutor.RunBytes(command, args...)
+	os.Stdout.Write(output)
+	return code, err
+}
@@ -0,0 +1,62 @@
+package exec
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sbreitf1/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+/* ############################################# */
+/* ###            RunInteractive             ### */
+/* ############################################# */
+
+func TestRunInteractive(t *testing.T) {
+	dir := t.TempDir()
+	defer redirectStdio(t, filepath.Join(dir, "stdin"), "user input\n", filepath.Join(dir, "stdout"))()
+
+	code, err := NewLocalExecutor().RunInteractive("sh", "-c", `read x; echo "got $x"; echo "oops" >&2; exit 4`)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, code)
+	assert.Equal(t, "got user input\noops\n", readFile(t, filepath.Join(dir, "stdout")))
+}
+
+func TestRunInteractiveError(t *testing.T) {
+	code, err := NewLocalExecutor().RunInteractive(path("noexec.txt"))
+	assert.True(t, errors.InstanceOf(err, ErrPermissionDenied))
+	assert.Equal(t, 0, code)
+}
+
+func TestRunInteractiveNonLocal(t *testing.T) {
+	dir := t.TempDir()
+	defer redirectStdio(t, filepath.Join(dir, "stdin"), "", filepath.Join(dir, "stdout"))()
+	defer restoreDefaultExecutor(DefaultExecutor)
+	DefaultExecutor = NewMockExecutor(func(command string, args ...string) (string, int, errors.Error) {
+		return "mocked output\n", 2, nil
+	})
+
+	code, err := RunInteractive("foo")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, code)
+	assert.Equal(t, "mocked output\n", readFile(t, filepath.Join(dir, "stdout")))
+}
+
+// redirectStdio replaces stdin by a file with the given content and stdout as well as stderr by the file at stdoutPath. The returned function restores the original streams.
+func redirectStdio(t *testing.T, stdinPath, stdin, stdoutPath string) func() {
+	assert.NoError(t, ioutil.WriteFile(stdinPath, []byte(stdin), 0666))
+	in, err := os.Open(stdinPath)
+	assert.NoError(t, err)
+	out, err := os.Create(stdoutPath)
+	assert.NoError(t, err)
+
+	origIn, origOut, origErr := os.Stdin, os.Stdout, os.Stderr
+	os.Stdin, os.Stdout, os.Stderr = in, out, out
+	return func() {
+		os.Stdin, os.Stdout, os.Stderr = origIn, origOut, origErr
+		in.Close()
+		out.Close()
+	}
+}
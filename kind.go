@@ -0,0 +1,67 @@
+package exec
+
+import (
+	"github.com/sbreitf1/errors"
+)
+
+// Kind classifies the errors returned by this package.
+type Kind int
+
+const (
+	// KindNone denotes the absence of an error.
+	KindNone Kind = iota
+	// KindParse denotes a malformed command line or command input (ErrParse, ErrTemplate, ErrInvalidArgv).
+	KindParse
+	// KindRun denotes a command that could not be executed (ErrRun, ErrCommandNotFound, ErrPermissionDenied, ErrArgsTooLong, ErrBadInterpreter, ErrCredential, ErrNiceness, ErrPTY, ErrOutputFile, ErrNotAllowed).
+	KindRun
+	// KindExit denotes a command that reported a failure by a non-zero exit code or by its output (ErrReturnCode, ErrFatalOutput, ErrNoMatch).
+	KindExit
+	// KindTimeout denotes a command that exceeded its time limit (ErrTimeout, ErrIdleTimeout).
+	KindTimeout
+	// KindCanceled denotes a command that has been stopped by context cancellation (ErrCanceled).
+	KindCanceled
+	// KindOther denotes all other errors, like errors that do not originate from this package, ErrSignal or errors of test executors like ErrUnexpectedCommand and ErrNoRecording.
+	KindOther
+)
+
+// String returns a human readable name of the kind.
+func (k Kind) String() string {
+	switch k {
+	case KindNone:
+		return "none"
+	case KindParse:
+		return "parse"
+	case KindRun:
+		return "run"
+	case KindExit:
+		return "exit"
+	case KindTimeout:
+		return "timeout"
+	case KindCanceled:
+		return "canceled"
+	default:
+		return "other"
+	}
+}
+
+// ErrorKind returns the kind of err to allow switching on error categories without checking every error type.
+func ErrorKind(err errors.Error) Kind {
+	if err == nil {
+		return KindNone
+	}
+
+	switch {
+	case errors.InstanceOf(err, ErrParse), errors.InstanceOf(err, ErrTemplate), errors.InstanceOf(err, ErrInvalidArgv):
+		return KindParse
+	case errors.InstanceOf(err, ErrRun), errors.InstanceOf(err, ErrCommandNotFound), errors.InstanceOf(err, ErrPermissionDenied), errors.InstanceOf(err, ErrArgsTooLong), errors.InstanceOf(err, ErrBadInterpreter), errors.InstanceOf(err, ErrCredential), errors.InstanceOf(err, ErrNiceness), errors.InstanceOf(err, ErrPTY), errors.InstanceOf(err, ErrOutputFile), errors.InstanceOf(err, ErrNotAllowed):
+		return KindRun
+	case errors.InstanceOf(err, ErrReturnCode), errors.InstanceOf(err, ErrFatalOutput), errors.InstanceOf(err, ErrNoMatch):
+		return KindExit
+	case errors.InstanceOf(err, ErrTimeout), errors.InstanceOf(err, ErrIdleTimeout):
+		return KindTimeout
+	case errors.InstanceOf(err, ErrCanceled):
+		return KindCanceled
+	default:
+		return KindOther
+	}
+}
@@ -0,0 +1,61 @@
+package exec
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/sbreitf1/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+/* ############################################# */
+/* ###                 Kind                  ### */
+/* ############################################# */
+
+func TestErrorKind(t *testing.T) {
+	assert.Equal(t, KindNone, ErrorKind(nil))
+	assert.Equal(t, KindParse, ErrorKind(ErrParse.Make()))
+	assert.Equal(t, KindRun, ErrorKind(ErrRun.Make()))
+	assert.Equal(t, KindExit, ErrorKind(ErrReturnCode.Args(1).Make()))
+	assert.Equal(t, KindTimeout, ErrorKind(ErrTimeout.Make()))
+	assert.Equal(t, KindCanceled, ErrorKind(ErrCanceled.Make()))
+	assert.Equal(t, KindOther, ErrorKind(errors.GenericError.Make()))
+}
+
+func TestErrorKindPackageErrors(t *testing.T) {
+	assert.Equal(t, KindParse, ErrorKind(ErrTemplate.Make()))
+	assert.Equal(t, KindParse, ErrorKind(ErrInvalidArgv.Make()))
+	assert.Equal(t, KindRun, ErrorKind(ErrOutputFile.Args("out.txt").Make()))
+	assert.Equal(t, KindRun, ErrorKind(ErrNotAllowed.Args("rm").Make()))
+	assert.Equal(t, KindExit, ErrorKind(ErrFatalOutput.Args("panic").Make()))
+	assert.Equal(t, KindExit, ErrorKind(ErrNoMatch.Make()))
+	assert.Equal(t, KindOther, ErrorKind(ErrSignal.Make()))
+	assert.Equal(t, KindOther, ErrorKind(ErrUnexpectedCommand.Args("x").Make()))
+}
+
+func TestErrorKindFromExecutors(t *testing.T) {
+	_, err := ExpandLineTemplate(`echo {{missing}}`, nil)
+	assert.Equal(t, KindParse, ErrorKind(err))
+	_, _, err = NewValidatingExecutor(NewMockExecutor(nil), AllowCommands("ls")).Run("rm")
+	assert.Equal(t, KindRun, ErrorKind(err))
+	_, err = RunToFile("/nonexistent/dir/out.txt", path("success.sh"))
+	assert.Equal(t, KindRun, ErrorKind(err))
+	_, _, err = NewLocalExecutor(WithFatalOutputPatterns(regexp.MustCompile("test output"))).Run(path("success.sh"))
+	assert.Equal(t, KindExit, ErrorKind(err))
+}
+
+func TestErrorKindFromRun(t *testing.T) {
+	_, _, err := RunLine(`newcommand "test`)
+	assert.Equal(t, KindParse, ErrorKind(err))
+	_, _, err = Run(path("noexec.txt"))
+	assert.Equal(t, KindRun, ErrorKind(err))
+	_, err = ShouldRun(path("fail.sh"))
+	assert.Equal(t, KindExit, ErrorKind(err))
+	_, err = ShouldRun(path("success.sh"))
+	assert.Equal(t, KindNone, ErrorKind(err))
+}
+
+func TestKindString(t *testing.T) {
+	assert.Equal(t, "timeout", KindTimeout.String())
+	assert.Equal(t, "other", Kind(-1).String())
+}
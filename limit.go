@@ -0,0 +1,46 @@
+package exec
+
+import (
+	"context"
+	"sync"
+
+	"github.com/sbreitf1/errors"
+)
+
+var (
+	slotsMutex sync.Mutex
+	slots      chan struct{}
+)
+
+// SetMaxConcurrent limits the number of commands executed simultaneously by all LocalExecutors to n. Further commands wait for a running command to finish before they are started, or return ErrCanceled or ErrTimeout if their context is done while waiting. A pipeline counts as a single command. Processes launched using Start are not limited. Use 0 to remove the limit, which is the default.
+func SetMaxConcurrent(n int) {
+	slotsMutex.Lock()
+	defer slotsMutex.Unlock()
+	if n <= 0 {
+		slots = nil
+	} else {
+		slots = make(chan struct{}, n)
+	}
+}
+
+// acquireSlot waits for a free execution slot and returns a function to release it again. Slots are always released to the limit they have been acquired from, so changing the limit does not affect running commands. An error is returned without acquiring a slot if ctx is already done.
+func acquireSlot(ctx context.Context) (func(), errors.Error) {
+	// select chooses randomly if a slot is free as well, so a done context must be checked first
+	if err := ctx.Err(); err != nil {
+		return nil, contextError(err)
+	}
+
+	slotsMutex.Lock()
+	current := slots
+	slotsMutex.Unlock()
+	if current == nil {
+		return func() {}, nil
+	}
+
+	select {
+	case current <- struct{}{}:
+		return func() { <-current }, nil
+	case <-ctx.Done():
+		return nil, contextError(ctx.Err())
+	}
+}
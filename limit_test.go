@@ -0,0 +1,122 @@
+package exec
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sbreitf1/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+/* ############################################# */
+/* ###            Max Concurrent             ### */
+/* ############################################# */
+
+func TestSetMaxConcurrent(t *testing.T) {
+	SetMaxConcurrent(1)
+	defer SetMaxConcurrent(0)
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			out, _, err := Run(path("sleep.sh"))
+			assert.NoError(t, err)
+			assert.Equal(t, "awake\n", out)
+		}()
+	}
+	wg.Wait()
+	assert.True(t, time.Since(start) >= 600*time.Millisecond)
+}
+
+func TestSetMaxConcurrentPipeline(t *testing.T) {
+	SetMaxConcurrent(1)
+	defer SetMaxConcurrent(0)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		Run(path("sleep.sh"))
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	// the pipeline waits for the running command, but all stages run at once
+	start := time.Now()
+	out, _, err := NewLocalExecutor().RunPipeline(CommandSpec{Command: path("sleep.sh")}, CommandSpec{Command: "cat"})
+	assert.NoError(t, err)
+	assert.Equal(t, "awake\n", out)
+	assert.True(t, time.Since(start) >= 450*time.Millisecond)
+	<-done
+}
+
+func TestSetMaxConcurrentUnlimited(t *testing.T) {
+	SetMaxConcurrent(1)
+	SetMaxConcurrent(0)
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _, err := Run(path("sleep.sh"))
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+	assert.True(t, time.Since(start) < 900*time.Millisecond)
+}
+
+func TestSetMaxConcurrentContext(t *testing.T) {
+	SetMaxConcurrent(1)
+	defer SetMaxConcurrent(0)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		Run(path("sleep.sh"))
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, _, err := RunContext(ctx, path("success.sh"))
+	assert.True(t, errors.InstanceOf(err, ErrTimeout))
+	assert.True(t, time.Since(start) < 200*time.Millisecond)
+
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	_, _, err = RunContext(ctx, path("success.sh"))
+	assert.True(t, errors.InstanceOf(err, ErrCanceled))
+
+	// the slot is available again as soon as the running command finished
+	<-done
+	_, _, err = Run(path("success.sh"))
+	assert.NoError(t, err)
+}
+
+func TestSetMaxConcurrentContextDone(t *testing.T) {
+	SetMaxConcurrent(1)
+	defer SetMaxConcurrent(0)
+
+	// a slot is free, but the context must take precedence
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for i := 0; i < 100; i++ {
+		release, err := acquireSlot(ctx)
+		if !assert.True(t, errors.InstanceOf(err, ErrCanceled)) {
+			release()
+			return
+		}
+	}
+
+	// no slot has been leaked
+	release, err := acquireSlot(context.Background())
+	assert.NoError(t, err)
+	release()
+}
@@ -0,0 +1,15 @@
+package exec
+
+import (
+	"strings"
+)
+
+var newlineReplacer = strings.NewReplacer("\r\n", "\n", "\r", "\n")
+
+// NormalizeNewlines converts Windows line endings "\r\n" and lone carriage returns "\r" to "\n".
+func NormalizeNewlines(s string) string {
+	if strings.IndexByte(s, '\r') < 0 {
+		return s
+	}
+	return newlineReplacer.Replace(s)
+}
@@ -0,0 +1,47 @@
+package exec
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/text/encoding/unicode"
+)
+
+/* ############################################# */
+/* ###           NormalizeNewlines           ### */
+/* ############################################# */
+
+func TestNormalizeNewlines(t *testing.T) {
+	assert.Equal(t, "", NormalizeNewlines(""))
+	assert.Equal(t, "a\nb\n", NormalizeNewlines("a\nb\n"))
+	assert.Equal(t, "a\nb\n", NormalizeNewlines("a\r\nb\r\n"))
+	assert.Equal(t, "a\nb\n", NormalizeNewlines("a\rb\r"))
+	assert.Equal(t, "a\n\nb\n\n", NormalizeNewlines("a\r\rb\n\r\n"))
+	assert.Equal(t, "a\n\nb", NormalizeNewlines("a\r\r\nb"))
+}
+
+func TestLocalExecutorNormalizeNewlines(t *testing.T) {
+	out, code, err := NewLocalExecutor(WithNormalizeNewlines(true)).Run("printf", `a\r\nb\rc\n`)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "a\nb\nc\n", out)
+
+	out, _, err = NewLocalExecutor(WithNormalizeNewlines(false)).Run("printf", `a\r\nb\rc\n`)
+	assert.NoError(t, err)
+	assert.Equal(t, "a\r\nb\rc\n", out)
+}
+
+func TestLocalExecutorNormalizeNewlinesComposition(t *testing.T) {
+	e := NewLocalExecutor(
+		WithOutputEncoding(unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM)),
+		WithNormalizeNewlines(true),
+	)
+	out, _, err := e.Run("printf", `a\000\r\000\n\000b\000\r\000`)
+	assert.NoError(t, err)
+	assert.Equal(t, "a\nb\n", out)
+
+	e = NewLocalExecutor(WithStripANSI(true), WithNormalizeNewlines(true))
+	out, _, err = e.Run("printf", `\033[31merror\033[0m\r\n50%%\r\033[2K100%%\r\n`)
+	assert.NoError(t, err)
+	assert.Equal(t, "error\n50%\n100%\n", out)
+}
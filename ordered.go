@@ -0,0 +1,107 @@
+package exec
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/sbreitf1/errors"
+)
+
+// OutputSource denotes the output stream a process has written to.
+type OutputSource int
+
+const (
+	// SourceStdout denotes the standard output of a process.
+	SourceStdout OutputSource = iota
+	// SourceStderr denotes the standard error output of a process.
+	SourceStderr
+)
+
+func (s OutputSource) String() string {
+	switch s {
+	case SourceStdout:
+		return "stdout"
+	case SourceStderr:
+		return "stderr"
+	default:
+		return "unknown"
+	}
+}
+
+// OutputLine is a single line of process output together with the stream it has been written to.
+type OutputLine struct {
+	Source OutputSource
+	Line   string
+}
+
+// RunOrdered executes a command line with separated arguments and returns the combined output along with all lines tagged by the stream they have been written to. Both are recorded in the order the output arrived.
+//
+// The order is best-effort: stdout and stderr are read from separate pipes, so output written by the process in quick succession may still be observed in a different order due to pipe buffering. Unterminated lines are reported at the end, stdout before stderr. Output filters are applied to every single line.
+func (e *LocalExecutor) RunOrdered(command string, args ...string) (string, []OutputLine, int, errors.Error) {
+	ordered := &orderedOutput{output: e.newOutputBuffer(nil)}
+	result := e.executeWith(context.Background(), nil, ordered.output, ordered.writer(SourceStdout), ordered.writer(SourceStderr), command, args...)
+	lines := ordered.Lines()
+	for i := range lines {
+		lines[i].Line = e.filterOutput(lines[i].Line)
+	}
+	return result.Output, lines, result.ExitCode, result.Err
+}
+
+// orderedOutput records the output of both streams in arrival order.
+type orderedOutput struct {
+	mutex   sync.Mutex
+	output  *outputBuffer
+	lines   []OutputLine
+	partial [2]strings.Builder
+}
+
+func (o *orderedOutput) writer(source OutputSource) *orderedWriter {
+	return &orderedWriter{ordered: o, source: source}
+}
+
+func (o *orderedOutput) write(source OutputSource, p []byte) (int, error) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	o.output.Write(p)
+
+	n := len(p)
+	partial := &o.partial[source]
+	for len(p) > 0 {
+		i := bytes.IndexByte(p, '\n')
+		if i < 0 {
+			partial.Write(p)
+			break
+		}
+		partial.Write(p[:i])
+		o.lines = append(o.lines, OutputLine{Source: source, Line: partial.String()})
+		partial.Reset()
+		p = p[i+1:]
+	}
+	return n, nil
+}
+
+// Lines returns all recorded lines including unterminated lines of both streams.
+func (o *orderedOutput) Lines() []OutputLine {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	lines := append([]OutputLine{}, o.lines...)
+	for _, source := range []OutputSource{SourceStdout, SourceStderr} {
+		if o.partial[source].Len() > 0 {
+			lines = append(lines, OutputLine{Source: source, Line: o.partial[source].String()})
+		}
+	}
+	return lines
+}
+
+// orderedWriter forwards all data written to it to an orderedOutput using a fixed source.
+type orderedWriter struct {
+	ordered *orderedOutput
+	source  OutputSource
+}
+
+func (w *orderedWriter) Write(p []byte) (int, error) {
+	return w.ordered.write(w.source, p)
+}
@@ -0,0 +1,35 @@
+package exec
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+/* ############################################# */
+/* ###               RunOrdered              ### */
+/* ############################################# */
+
+func TestRunOrdered(t *testing.T) {
+	out, lines, code, err := NewLocalExecutor().RunOrdered(path("ordered.sh"))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, code)
+	assert.Equal(t, "out 1\nerr 1\nout 2\nerr 2", out)
+	assert.Equal(t, []OutputLine{
+		{Source: SourceStdout, Line: "out 1"},
+		{Source: SourceStderr, Line: "err 1"},
+		{Source: SourceStdout, Line: "out 2"},
+		{Source: SourceStderr, Line: "err 2"},
+	}, lines)
+}
+
+func TestRunOrderedError(t *testing.T) {
+	_, lines, _, err := NewLocalExecutor().RunOrdered(path("noexec.txt"))
+	assert.Error(t, err)
+	assert.Empty(t, lines)
+}
+
+func TestOutputSourceString(t *testing.T) {
+	assert.Equal(t, "stdout", SourceStdout.String())
+	assert.Equal(t, "stderr", SourceStderr.String())
+}
@@ -0,0 +1,92 @@
+package exec
+
+import (
+	"github.com/sbreitf1/errors"
+)
+
+// ParseErrorKind classifies the reason of a parse error.
+type ParseErrorKind int
+
+const (
+	// ParseErrorNone denotes errors that are not parse errors.
+	ParseErrorNone ParseErrorKind = iota
+	// ParseErrorUnterminatedSingleQuote denotes a single quote that is not closed.
+	ParseErrorUnterminatedSingleQuote
+	// ParseErrorUnterminatedDoubleQuote denotes a double quote that is not closed.
+	ParseErrorUnterminatedDoubleQuote
+	// ParseErrorUnterminatedANSICQuote denotes an ANSI-C quote ($'...') that is not closed.
+	ParseErrorUnterminatedANSICQuote
+	// ParseErrorDanglingEscape denotes an escape rune at the end of the command line.
+	ParseErrorDanglingEscape
+	// ParseErrorInvalidNullChar denotes a NUL character inside the command line.
+	ParseErrorInvalidNullChar
+	// ParseErrorEmptyInput denotes a command line without any command.
+	ParseErrorEmptyInput
+	// ParseErrorLimitExceeded denotes a command line exceeding the limits of the parse config.
+	ParseErrorLimitExceeded
+	// ParseErrorInvalidConfig denotes an invalid parse config.
+	ParseErrorInvalidConfig
+	// ParseErrorSyntax denotes misplaced operators like pipes or redirections.
+	ParseErrorSyntax
+)
+
+// String returns a human readable name of the kind.
+func (k ParseErrorKind) String() string {
+	switch k {
+	case ParseErrorNone:
+		return "none"
+	case ParseErrorUnterminatedSingleQuote:
+		return "unterminated single quote"
+	case ParseErrorUnterminatedDoubleQuote:
+		return "unterminated double quote"
+	case ParseErrorUnterminatedANSICQuote:
+		return "unterminated ANSI-C quote"
+	case ParseErrorDanglingEscape:
+		return "dangling escape"
+	case ParseErrorInvalidNullChar:
+		return "invalid null char"
+	case ParseErrorEmptyInput:
+		return "empty input"
+	case ParseErrorLimitExceeded:
+		return "limit exceeded"
+	case ParseErrorInvalidConfig:
+		return "invalid config"
+	case ParseErrorSyntax:
+		return "syntax"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseError is returned as ErrParse by all parse functions and carries the reason of the failure.
+type ParseError struct {
+	baseError
+	Kind ParseErrorKind
+}
+
+// newParseError returns an ErrParse error of the given kind with a formatted message.
+func newParseError(kind ParseErrorKind, msg string, args ...interface{}) errors.Error {
+	return &ParseError{baseError: ErrParse.Make().Msg(msg, args...), Kind: kind}
+}
+
+// ParseErrorKindOf returns the kind of a parse error or ParseErrorNone if err is no parse error.
+func ParseErrorKindOf(err error) ParseErrorKind {
+	if e, ok := err.(*ParseError); ok {
+		return e.Kind
+	}
+	return ParseErrorNone
+}
+
+// unterminatedKind returns the kind of error for a command line that ended in the given parser state.
+func unterminatedKind(state int) ParseErrorKind {
+	switch state {
+	case parseSingleQuote:
+		return ParseErrorUnterminatedSingleQuote
+	case parseDoubleQuote:
+		return ParseErrorUnterminatedDoubleQuote
+	case parseANSICQuote:
+		return ParseErrorUnterminatedANSICQuote
+	default:
+		return ParseErrorDanglingEscape
+	}
+}
@@ -0,0 +1,52 @@
+package exec
+
+import (
+	"testing"
+
+	"github.com/sbreitf1/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+/* ############################################# */
+/* ###              ParseError               ### */
+/* ############################################# */
+
+func TestParseErrorKind(t *testing.T) {
+	for commandLine, kind := range map[string]ParseErrorKind{
+		`newcommand 'test`:    ParseErrorUnterminatedSingleQuote,
+		`newcommand "test`:    ParseErrorUnterminatedDoubleQuote,
+		`newcommand "test\`:   ParseErrorUnterminatedDoubleQuote,
+		`newcommand $'test`:   ParseErrorUnterminatedANSICQuote,
+		`newcommand test\`:    ParseErrorDanglingEscape,
+		"newcommand te\000st": ParseErrorInvalidNullChar,
+		"  ":                  ParseErrorEmptyInput,
+	} {
+		_, _, err := Parse(commandLine)
+		assert.True(t, errors.InstanceOf(err, ErrParse), commandLine)
+		assert.Equal(t, KindParse, ErrorKind(err), commandLine)
+		assert.Equal(t, kind, ParseErrorKindOf(err), commandLine)
+	}
+}
+
+func TestParseErrorKindLimits(t *testing.T) {
+	_, _, err := ParseLimited("a b c", 2, 0)
+	assert.Equal(t, ParseErrorLimitExceeded, ParseErrorKindOf(err))
+
+	_, _, err = ParseWith("a", ParseConfig{SingleQuote: '$', DoubleQuote: dqt, Escape: esc})
+	assert.Equal(t, ParseErrorInvalidConfig, ParseErrorKindOf(err))
+}
+
+func TestParseErrorKindOperators(t *testing.T) {
+	_, err := ParsePipeline(`a | | b`)
+	assert.Equal(t, ParseErrorSyntax, ParseErrorKindOf(err))
+	_, err = ParsePipeline(`a >`)
+	assert.Equal(t, ParseErrorSyntax, ParseErrorKindOf(err))
+	_, err = ParseSequence(`;`)
+	assert.Equal(t, ParseErrorEmptyInput, ParseErrorKindOf(err))
+}
+
+func TestParseErrorKindOther(t *testing.T) {
+	assert.Equal(t, ParseErrorNone, ParseErrorKindOf(nil))
+	assert.Equal(t, ParseErrorNone, ParseErrorKindOf(ErrRun.Make()))
+	assert.Equal(t, "unterminated single quote", ParseErrorUnterminatedSingleQuote.String())
+}
@@ -0,0 +1,225 @@
+package exec
+
+import (
+	"context"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/sbreitf1/errors"
+)
+
+const (
+	opPipe = "|"
+)
+
+// RedirectOp denotes the kind of a redirection.
+type RedirectOp string
+
+const (
+	// RedirectOut redirects stdout to a file (>).
+	RedirectOut RedirectOp = ">"
+	// RedirectAppend appends stdout to a file (>>).
+	RedirectAppend RedirectOp = ">>"
+	// RedirectIn reads stdin from a file (<).
+	RedirectIn RedirectOp = "<"
+	// RedirectErr redirects stderr to a file (2>).
+	RedirectErr RedirectOp = "2>"
+	// RedirectErrAppend appends stderr to a file (2>>).
+	RedirectErrAppend RedirectOp = "2>>"
+)
+
+// Redirect denotes a single redirection of a command.
+type Redirect struct {
+	Op     RedirectOp
+	Target string
+}
+
+// PipelineCommand denotes a single command of a pipeline with its redirections.
+type PipelineCommand struct {
+	Command   string
+	Args      []string
+	Redirects []Redirect
+}
+
+// Pipeline denotes a sequence of commands where the output of each command is passed to the next one.
+type Pipeline struct {
+	Commands []PipelineCommand
+}
+
+var pipelineOperators = []string{opPipe, string(RedirectOut), string(RedirectAppend), string(RedirectIn), string(RedirectErr), string(RedirectErrAppend)}
+
+// ParsePipeline parses a command line with pipes (|) and redirections (>, >>, <, 2>, 2>>) into a structured representation without executing anything. Quoted or escaped operators are kept literally.
+func ParsePipeline(commandLine string) (*Pipeline, errors.Error) {
+	tokens, err := tokenize(commandLine, DefaultParseConfig(), pipelineOperators)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, newParseError(ParseErrorEmptyInput, "Unexpected end of command line")
+	}
+
+	pipeline := &Pipeline{Commands: make([]PipelineCommand, 0)}
+	var words []string
+	var redirects []Redirect
+	finishCommand := func() errors.Error {
+		if len(words) == 0 {
+			return newParseError(ParseErrorSyntax, "Missing command in pipeline")
+		}
+		command := PipelineCommand{Command: words[0], Redirects: redirects}
+		if len(words) > 1 {
+			command.Args = words[1:]
+		}
+		pipeline.Commands = append(pipeline.Commands, command)
+		words = nil
+		redirects = nil
+		return nil
+	}
+
+	for i := 0; i < len(tokens); i++ {
+		t := tokens[i]
+		switch {
+		case !t.operator:
+			words = append(words, t.value)
+
+		case t.value == opPipe:
+			if err := finishCommand(); err != nil {
+				return nil, err
+			}
+
+		default:
+			if i+1 >= len(tokens) || tokens[i+1].operator {
+				return nil, newParseError(ParseErrorSyntax, "Missing target for redirection %s", t.value)
+			}
+			redirects = append(redirects, Redirect{Op: RedirectOp(t.value), Target: tokens[i+1].value})
+			i++
+		}
+	}
+	if err := finishCommand(); err != nil {
+		return nil, err
+	}
+	return pipeline, nil
+}
+
+// RunPipeline executes the given stages like RunPipelineContext without a context.
+func (e *LocalExecutor) RunPipeline(stages ...CommandSpec) (string, int, errors.Error) {
+	return e.RunPipelineContext(context.Background(), stages...)
+}
+
+// RunPipelineContext executes all stages concurrently and connects stdout of each stage to stdin of the next one. The returned output contains stdout of the last stage and stderr of all stages, the exit code is the one of the last stage like in a shell without pipefail. The timeout of the executor applies to the whole pipeline.
+//
+// When ctx is cancelled, every stage is stopped and the pipes between them are closed, so a stuck stage cannot stall the pipeline. The output captured so far is returned along with ErrCanceled in this case, or ErrTimeout if the deadline of ctx has been exceeded. All processes have exited and all internal goroutines have finished when this method returns.
+func (e *LocalExecutor) RunPipelineContext(ctx context.Context, stages ...CommandSpec) (string, int, errors.Error) {
+	if len(stages) == 0 {
+		return "", 0, ErrRun.Make().Msg("Pipeline does not contain any command")
+	}
+
+	release, err := acquireSlot(ctx)
+	if err != nil {
+		return "", 0, err
+	}
+	defer release()
+
+	start := time.Now()
+	output := e.newOutputBuffer(nil)
+	handles, pipes, err := e.launchPipeline(output, stages)
+	if err != nil {
+		return "", 0, err
+	}
+
+	waitCtx := ctx
+	if e.timeout > 0 {
+		var cancel context.CancelFunc
+		waitCtx, cancel = context.WithTimeout(waitCtx, e.timeout)
+		defer cancel()
+	}
+
+	results := make([]RunResult, len(handles))
+	var wg sync.WaitGroup
+	for i := range handles {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, results[i].ExitCode, results[i].Err = handles[i].WaitContext(waitCtx)
+			// the next stage receives EOF as soon as this stage has exited
+			if pipes[i] != nil {
+				pipes[i].Close()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	duration := time.Since(start)
+	for i, stage := range stages {
+		results[i].Duration = duration
+		notifyRun(stage.Command, stage.Args, results[i])
+	}
+
+	code := results[len(results)-1].ExitCode
+	out := e.filterOutput(e.decodeOutput(output.String()))
+	if ctx.Err() != nil {
+		return out, code, contextError(ctx.Err())
+	}
+	if waitCtx.Err() == context.DeadlineExceeded {
+		return out, code, ErrTimeout.Make().Cause(waitCtx.Err())
+	}
+	for _, result := range results {
+		if result.Err != nil {
+			return out, code, result.Err
+		}
+	}
+	return out, code, e.checkFatalOutput(out)
+}
+
+// launchPipeline starts all stages connected by pipes and returns their handles along with the write end of the pipe to the next stage, which is nil for the last stage. All started processes are killed if a stage cannot be started.
+func (e *LocalExecutor) launchPipeline(output *outputBuffer, stages []CommandSpec) ([]*RunHandle, []*os.File, errors.Error) {
+	handles := make([]*RunHandle, 0, len(stages))
+	pipes := make([]*os.File, 0, len(stages))
+	abort := func(err errors.Error) ([]*RunHandle, []*os.File, errors.Error) {
+		for i, h := range handles {
+			h.Kill()
+			h.Wait()
+			pipes[i].Close()
+		}
+		return nil, nil, err
+	}
+
+	var stdin io.Reader
+	var reader *os.File
+	for i, stage := range stages {
+		var stdout io.Writer = output
+		var writer, next *os.File
+		if i < len(stages)-1 {
+			var err error
+			next, writer, err = os.Pipe()
+			if err != nil {
+				if reader != nil {
+					reader.Close()
+				}
+				return abort(ErrRun.Make().Cause(err))
+			}
+			stdout = writer
+		}
+
+		h, err := e.launch(stdin, output, stdout, output, stage.Command, stage.Args...)
+		// the read end has been passed to the child process and is not needed here anymore
+		if reader != nil {
+			reader.Close()
+		}
+		if err != nil {
+			if writer != nil {
+				writer.Close()
+				next.Close()
+			}
+			return abort(err)
+		}
+
+		handles = append(handles, h)
+		pipes = append(pipes, writer)
+		if next != nil {
+			reader, stdin = next, next
+		}
+	}
+	return handles, pipes, nil
+}
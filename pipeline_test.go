@@ -0,0 +1,129 @@
+package exec
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sbreitf1/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+/* ############################################# */
+/* ###             ParsePipeline             ### */
+/* ############################################# */
+
+func TestParsePipeline(t *testing.T) {
+	p, err := ParsePipeline(`cat < in.txt | grep -v "foo bar" | sort > out.txt 2> err.txt`)
+	assert.NoError(t, err)
+	assert.Equal(t, &Pipeline{Commands: []PipelineCommand{
+		{Command: "cat", Redirects: []Redirect{{RedirectIn, "in.txt"}}},
+		{Command: "grep", Args: []string{"-v", "foo bar"}},
+		{Command: "sort", Redirects: []Redirect{{RedirectOut, "out.txt"}, {RedirectErr, "err.txt"}}},
+	}}, p)
+}
+
+func TestParsePipelineNoSpaces(t *testing.T) {
+	p, err := ParsePipeline(`a|b>>log 2>>errlog<in`)
+	assert.NoError(t, err)
+	assert.Equal(t, &Pipeline{Commands: []PipelineCommand{
+		{Command: "a"},
+		{Command: "b", Redirects: []Redirect{{RedirectAppend, "log"}, {RedirectErrAppend, "errlog"}, {RedirectIn, "in"}}},
+	}}, p)
+}
+
+func TestParsePipelineDigitInWord(t *testing.T) {
+	p, err := ParsePipeline(`echo a2>f 2`)
+	assert.NoError(t, err)
+	assert.Equal(t, &Pipeline{Commands: []PipelineCommand{
+		{Command: "echo", Args: []string{"a2", "2"}, Redirects: []Redirect{{RedirectOut, "f"}}},
+	}}, p)
+}
+
+func TestParsePipelineQuotedOperators(t *testing.T) {
+	p, err := ParsePipeline(`echo "a | b" '>' \< "2>" x\|y`)
+	assert.NoError(t, err)
+	assert.Equal(t, &Pipeline{Commands: []PipelineCommand{
+		{Command: "echo", Args: []string{"a | b", ">", "<", "2>", "x|y"}},
+	}}, p)
+}
+
+func TestParsePipelineRoundTrip(t *testing.T) {
+	args := []string{"a|b", "out>f", "2>x", "2>>x", "<in", ">", "|", "x&&y;z"}
+	for _, style := range []QuoteStyle{StyleAuto, StyleRaw, StyleReadable} {
+		p, err := ParsePipeline(GetCommandLineStyle(style, "echo", args...))
+		assert.NoError(t, err)
+		assert.Equal(t, &Pipeline{Commands: []PipelineCommand{{Command: "echo", Args: args}}}, p)
+	}
+}
+
+func TestParsePipelineErrors(t *testing.T) {
+	for _, commandLine := range []string{``, `| a`, `a |`, `a || b`, `a >`, `a > | b`, `> f`, `a "b`} {
+		_, err := ParsePipeline(commandLine)
+		assert.True(t, errors.InstanceOf(err, ErrParse), commandLine)
+	}
+}
+
+/* ############################################# */
+/* ###              RunPipeline              ### */
+/* ############################################# */
+
+func TestRunPipeline(t *testing.T) {
+	out, code, err := NewLocalExecutor().RunPipeline(
+		CommandSpec{Command: "printf", Args: []string{`b\na\n`}},
+		CommandSpec{Command: "sort"},
+		CommandSpec{Command: "tr", Args: []string{"a-z", "A-Z"}},
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "A\nB\n", out)
+}
+
+func TestRunPipelineExitCode(t *testing.T) {
+	out, code, err := NewLocalExecutor().RunPipeline(
+		CommandSpec{Command: path("success.sh")},
+		CommandSpec{Command: "sh", Args: []string{"-c", "cat; echo oops >&2; exit 4"}},
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, code)
+	assert.Equal(t, "some test output here\noops\n", out)
+}
+
+func TestRunPipelineEmpty(t *testing.T) {
+	_, _, err := NewLocalExecutor().RunPipeline()
+	assert.True(t, errors.InstanceOf(err, ErrRun))
+}
+
+func TestRunPipelineStartError(t *testing.T) {
+	_, _, err := NewLocalExecutor().RunPipeline(
+		CommandSpec{Command: "sleep", Args: []string{"30"}},
+		CommandSpec{Command: path("noexec.txt")},
+	)
+	assert.True(t, errors.InstanceOf(err, ErrPermissionDenied))
+}
+
+func TestRunPipelineContextCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	var out string
+	var err errors.Error
+	go func() {
+		defer close(done)
+		// the middle stage never reads its input nor writes any output
+		out, _, err = NewLocalExecutor().RunPipelineContext(ctx,
+			CommandSpec{Command: path("success.sh")},
+			CommandSpec{Command: "sleep", Args: []string{"30"}},
+			CommandSpec{Command: "cat"},
+		)
+	}()
+
+	time.Sleep(200 * time.Millisecond)
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("pipeline did not return after cancellation")
+	}
+	assert.True(t, errors.InstanceOf(err, ErrCanceled))
+	assert.Equal(t, "", out)
+}
@@ -0,0 +1,114 @@
+package exec
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+// drainTimeout denotes how long output is still collected after a stopped process has exited.
+const drainTimeout = 100 * time.Millisecond
+
+// outputPipes connects stdout and stderr of a command to pipes owned by the executor instead of letting exec.Cmd copy the output. Unlike exec.Cmd, the pipes can be closed after the process has been stopped, so children that inherited and still hold the pipes cannot delay the result. Writers that are files are passed to the process directly.
+type outputPipes struct {
+	readers []*os.File
+	writers []*os.File
+	targets []io.Writer
+}
+
+// newOutputPipes replaces stdout and stderr of cmd by pipes if required. Stdout and stderr share a single pipe if they denote the same writer.
+func newOutputPipes(cmd *exec.Cmd) (*outputPipes, error) {
+	p := &outputPipes{}
+	stdout, err := p.pipe(cmd.Stdout)
+	if err != nil {
+		return nil, err
+	}
+	stderr := stdout
+	if !sameWriter(cmd.Stdout, cmd.Stderr) {
+		if stderr, err = p.pipe(cmd.Stderr); err != nil {
+			p.close()
+			return nil, err
+		}
+	}
+	cmd.Stdout, cmd.Stderr = stdout, stderr
+	return p, nil
+}
+
+// pipe returns the writer to pass to the process for w.
+func (p *outputPipes) pipe(w io.Writer) (io.Writer, error) {
+	if w == nil {
+		return nil, nil
+	}
+	if f, ok := w.(*os.File); ok {
+		return f, nil
+	}
+
+	r, pw, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	p.readers = append(p.readers, r)
+	p.writers = append(p.writers, pw)
+	p.targets = append(p.targets, w)
+	return pw, nil
+}
+
+// collect closes the write ends inherited by the started process and copies all output to the targets. The returned channel is closed as soon as all output has been copied.
+func (p *outputPipes) collect() <-chan struct{} {
+	for _, w := range p.writers {
+		w.Close()
+	}
+
+	drained := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := range p.readers {
+		wg.Add(1)
+		go func(r *os.File, w io.Writer) {
+			defer wg.Done()
+			defer r.Close()
+			io.Copy(w, r)
+		}(p.readers[i], p.targets[i])
+	}
+	go func() {
+		wg.Wait()
+		close(drained)
+	}()
+	return drained
+}
+
+// abort stops copying output after the data that is already buffered in the pipes has been copied.
+func (p *outputPipes) abort() {
+	for _, r := range p.readers {
+		stopReading(r)
+	}
+}
+
+// close releases all pipes of a command that could not be started.
+func (p *outputPipes) close() {
+	for _, w := range p.writers {
+		w.Close()
+	}
+	for _, r := range p.readers {
+		r.Close()
+	}
+}
+
+// stopReading makes pending and future reads of f fail after a short time that is sufficient to read all buffered data of an exited process. The file is closed right away if it does not support deadlines.
+func stopReading(f *os.File) {
+	if err := f.SetReadDeadline(time.Now().Add(drainTimeout)); err != nil {
+		f.Close()
+	}
+}
+
+// sameWriter returns true if a and b denote the same writer like exec.Cmd does to share a single pipe for stdout and stderr.
+func sameWriter(a, b io.Writer) (equal bool) {
+	defer func() {
+		// writers of non-comparable types are never equal
+		if recover() != nil {
+			equal = false
+		}
+	}()
+	return a == b
+}
@@ -0,0 +1,45 @@
+package exec
+
+import (
+	"strings"
+)
+
+// QuotePowerShell returns a safe representation of the given string for PowerShell command lines. Strings that only consist of unambiguous characters are returned unmodified, all others are enclosed in single quotes where backticks and dollar signs are not interpreted. Single quotes, including their typographic variants, are escaped by doubling.
+func QuotePowerShell(str string) string {
+	if len(str) == 0 {
+		return "''"
+	}
+	if strings.IndexFunc(str, isPowerShellSpecial) < 0 {
+		return str
+	}
+
+	var sb strings.Builder
+	sb.WriteRune(sqt)
+	for _, r := range str {
+		if isPowerShellQuote(r) {
+			sb.WriteRune(r)
+		}
+		sb.WriteRune(r)
+	}
+	sb.WriteRune(sqt)
+	return sb.String()
+}
+
+// GetCommandLinePowerShell assembles a single command line for PowerShell using QuotePowerShell for the command and all arguments. Use the call operator "&" to invoke the result if the command itself needs quoting.
+func GetCommandLinePowerShell(command string, args ...string) string {
+	parts := make([]string, 0, len(args)+1)
+	parts = append(parts, QuotePowerShell(command))
+	for _, arg := range args {
+		parts = append(parts, QuotePowerShell(arg))
+	}
+	return strings.Join(parts, " ")
+}
+
+func isPowerShellSpecial(r rune) bool {
+	return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("_-./:\\=+", r))
+}
+
+// isPowerShellQuote returns true for all runes PowerShell accepts as single quote.
+func isPowerShellQuote(r rune) bool {
+	return r == sqt || r == '\u2018' || r == '\u2019' || r == '\u201a' || r == '\u201b'
+}
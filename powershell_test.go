@@ -0,0 +1,30 @@
+package exec
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+/* ############################################# */
+/* ###              PowerShell               ### */
+/* ############################################# */
+
+func TestQuotePowerShell(t *testing.T) {
+	assert.Equal(t, "''", QuotePowerShell(""))
+	assert.Equal(t, `C:\Windows\System32`, QuotePowerShell(`C:\Windows\System32`))
+	assert.Equal(t, "'foo bar'", QuotePowerShell("foo bar"))
+	assert.Equal(t, "'$env:PATH'", QuotePowerShell("$env:PATH"))
+	assert.Equal(t, "'it''s'", QuotePowerShell("it's"))
+	assert.Equal(t, `'say "hi"'`, QuotePowerShell(`say "hi"`))
+	assert.Equal(t, "'a`b'", QuotePowerShell("a`b"))
+	assert.Equal(t, "'it’’s'", QuotePowerShell("it’s"))
+	assert.Equal(t, "'a;b'", QuotePowerShell("a;b"))
+	// parameter names must stay unquoted to be recognized by cmdlets
+	assert.Equal(t, "-Name", QuotePowerShell("-Name"))
+}
+
+func TestGetCommandLinePowerShell(t *testing.T) {
+	cmdLine := GetCommandLinePowerShell("Write-Output", "foo bar", "$x", "it's", "a`b", "")
+	assert.Equal(t, "Write-Output 'foo bar' '$x' 'it''s' 'a`b' ''", cmdLine)
+}
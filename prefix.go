@@ -0,0 +1,46 @@
+package exec
+
+import (
+	"github.com/sbreitf1/errors"
+)
+
+// PrefixExecutor prepends a fixed list of tokens to every command before it is run on an inner Executor. This allows to run all commands using wrappers like sudo, nice or timeout by swapping the executor.
+type PrefixExecutor struct {
+	inner  Executor
+	prefix []string
+}
+
+// RunLine parses the command line and runs it using Run. The prefix is prepended after parsing, so it is never subject to quoting of the command line.
+func (e *PrefixExecutor) RunLine(commandLine string) (string, int, errors.Error) {
+	return runParsed(e, commandLine)
+}
+
+// Run executes the prefixed command on the inner executor.
+func (e *PrefixExecutor) Run(command string, args ...string) (string, int, errors.Error) {
+	command, args = e.apply(command, args)
+	return e.inner.Run(command, args...)
+}
+
+// RunBytes executes the prefixed command on the inner executor.
+func (e *PrefixExecutor) RunBytes(command string, args ...string) ([]byte, int, errors.Error) {
+	command, args = e.apply(command, args)
+	return e.inner.RunBytes(command, args...)
+}
+
+// apply returns the command to execute with the prefix in front of command and args.
+func (e *PrefixExecutor) apply(command string, args []string) (string, []string) {
+	if len(e.prefix) == 0 {
+		return command, args
+	}
+
+	prefixed := make([]string, 0, len(e.prefix)+len(args))
+	prefixed = append(prefixed, e.prefix[1:]...)
+	prefixed = append(prefixed, command)
+	prefixed = append(prefixed, args...)
+	return e.prefix[0], prefixed
+}
+
+// NewPrefixExecutor returns an executor that runs all commands on inner with the given tokens in front, for example NewPrefixExecutor(inner, "sudo", "-n") to run everything as root. The first token is the command that is actually executed.
+func NewPrefixExecutor(inner Executor, prefix ...string) *PrefixExecutor {
+	return &PrefixExecutor{inner, append([]string{}, prefix...)}
+}
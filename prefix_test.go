@@ -0,0 +1,40 @@
+package exec
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+/* ############################################# */
+/* ###            PrefixExecutor             ### */
+/* ############################################# */
+
+func TestPrefixExecutor(t *testing.T) {
+	inner := NewDryRunExecutor(0)
+	e := NewPrefixExecutor(inner, "sudo", "-n")
+
+	e.Run("rm", "-rf", "/tmp/foo bar")
+	e.RunBytes("ls")
+	e.RunLine(`echo "it's" 'a b'`)
+	assert.Equal(t, []string{
+		`sudo -n rm -rf /tmp/foo\ bar`,
+		`sudo -n ls`,
+		`sudo -n echo it\'s a\ b`,
+	}, inner.Commands())
+}
+
+func TestPrefixExecutorEmpty(t *testing.T) {
+	inner := NewDryRunExecutor(0)
+	NewPrefixExecutor(inner).Run("ls", "-la")
+	assert.Equal(t, []string{"ls -la"}, inner.Commands())
+}
+
+func TestPrefixExecutorLocal(t *testing.T) {
+	e := NewPrefixExecutor(NewLocalExecutor(), "env", "FOO=bar")
+	out, code, err := e.Run("sh", "-c", "echo $FOO")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "bar", strings.TrimSpace(out))
+}
@@ -0,0 +1,51 @@
+//go:build !windows
+// +build !windows
+
+package exec
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+
+	"github.com/sbreitf1/errors"
+)
+
+// cleanPath denotes the PATH of commands run with WithCleanEnv if not configured explicitly.
+const cleanPath = "/usr/local/bin:/usr/bin:/bin"
+
+// setProcessGroup makes the command the leader of a new process group.
+func setProcessGroup(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setpgid = true
+}
+
+// setCredential runs the command as the given user and groups.
+func setCredential(cmd *exec.Cmd, c *credential) errors.Error {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Credential = &syscall.Credential{Uid: c.uid, Gid: c.gid, Groups: c.groups}
+	return nil
+}
+
+// setNiceness sets the nice value of the process with the given pid.
+func setNiceness(pid int, n int) error {
+	return syscall.Setpriority(syscall.PRIO_PROCESS, pid, n)
+}
+
+// signalProcessGroup sends sig to all processes in the process group led by p.
+func signalProcessGroup(p *os.Process, sig os.Signal) error {
+	s, ok := sig.(syscall.Signal)
+	if !ok {
+		return p.Signal(sig)
+	}
+	return syscall.Kill(-p.Pid, s)
+}
+
+// shellCommand returns the command and arguments to run a command line using the platform shell.
+func shellCommand(commandLine string) (string, []string) {
+	return "/bin/sh", []string{"-c", commandLine}
+}
@@ -0,0 +1,38 @@
+//go:build windows
+// +build windows
+
+package exec
+
+import (
+	"os"
+	"os/exec"
+
+	"github.com/sbreitf1/errors"
+)
+
+// cleanPath denotes the PATH of commands run with WithCleanEnv if not configured explicitly.
+const cleanPath = `C:\Windows\System32;C:\Windows`
+
+// setProcessGroup is not supported on Windows and leaves the command unchanged.
+func setProcessGroup(cmd *exec.Cmd) {
+}
+
+// setCredential always fails because credentials are not supported on Windows.
+func setCredential(cmd *exec.Cmd, c *credential) errors.Error {
+	return c.error().StrCause("Credentials are not supported on Windows")
+}
+
+// setNiceness always fails because nice values are not supported on Windows.
+func setNiceness(pid int, n int) error {
+	return errors.GenericError.Make().Msg("Niceness is not supported on Windows")
+}
+
+// signalProcessGroup falls back to signalling p only, as process groups are not supported on Windows.
+func signalProcessGroup(p *os.Process, sig os.Signal) error {
+	return p.Signal(sig)
+}
+
+// shellCommand returns the command and arguments to run a command line using the platform shell.
+func shellCommand(commandLine string) (string, []string) {
+	return "cmd", []string{"/c", commandLine}
+}
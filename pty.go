@@ -0,0 +1,72 @@
+package exec
+
+import (
+	"context"
+	"io"
+
+	"github.com/sbreitf1/errors"
+)
+
+const (
+	defaultPTYCols = 80
+	defaultPTYRows = 24
+)
+
+var (
+	// ErrPTY occurs when a pseudo terminal could not be allocated for a command, which always happens on platforms other than Linux.
+	ErrPTY = errors.New("Could not allocate pseudo terminal")
+)
+
+// RunPTY executes a command line with separated arguments attached to a newly allocated pseudo terminal and returns everything written to the terminal. RunPTY is only implemented for Linux. This allows to capture the output of tools that behave differently when not run in a terminal, for example with colors and progress bars. The output contains line breaks as produced by the terminal, which usually is \r\n. The terminal size is 80x24 unless configured using WithPTYSize.
+//
+// The command runs in a new session with the terminal as controlling terminal and reads from the terminal, so prompts never receive any input. Pseudo terminals are allocated using /dev/ptmx and /dev/pts as provided by Linux. All other platforms, including macOS, the BSDs and Windows, fail with ErrPTY without starting the command.
+func (e *LocalExecutor) RunPTY(command string, args ...string) (string, int, errors.Error) {
+	output := e.newOutputBuffer(nil)
+	result := e.executeLaunch(context.Background(), func() (*RunHandle, errors.Error) {
+		return e.launchPTY(output, command, args...)
+	}, command, args...)
+	return result.Output, result.ExitCode, result.Err
+}
+
+// launchPTY starts the command attached to a new pseudo terminal and copies all data written to the terminal to output. The terminal is closed when the returned handle is done.
+func (e *LocalExecutor) launchPTY(output *outputBuffer, command string, args ...string) (*RunHandle, errors.Error) {
+	cols, rows := e.ptyCols, e.ptyRows
+	if cols == 0 || rows == 0 {
+		cols, rows = defaultPTYCols, defaultPTYRows
+	}
+	master, tty, err := openPTY(cols, rows)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd, err := e.command(tty, tty, tty, command, args...)
+	if err != nil {
+		tty.Close()
+		master.Close()
+		return nil, err
+	}
+	setControllingTerminal(cmd)
+
+	var activity chan struct{}
+	var w io.Writer = output
+	if e.idleTimeout > 0 {
+		activity = make(chan struct{}, 1)
+		w = newActivityWriter(output, activity)
+	}
+	drained := make(chan struct{})
+	go func() {
+		defer close(drained)
+		defer master.Close()
+		// reading fails as soon as the terminal has been closed by all processes
+		io.Copy(w, master)
+	}()
+
+	h, err := e.startCommand(cmd, output, activity, drained, func() { stopReading(master) })
+	// the terminal has been passed to the process and is not needed here anymore
+	tty.Close()
+	if err != nil {
+		<-drained
+		return nil, err
+	}
+	return h, nil
+}
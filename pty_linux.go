@@ -0,0 +1,92 @@
+//go:build linux
+// +build linux
+
+package exec
+
+import (
+	"os"
+	"os/exec"
+	"strconv"
+	"syscall"
+	"unsafe"
+
+	"github.com/sbreitf1/errors"
+)
+
+// windowSize corresponds to struct winsize of the kernel.
+type windowSize struct {
+	Rows uint16
+	Cols uint16
+	X    uint16
+	Y    uint16
+}
+
+// openPTY allocates a new pseudo terminal of the given size and returns its master and terminal side.
+func openPTY(cols, rows uint16) (*os.File, *os.File, errors.Error) {
+	master, err := os.OpenFile("/dev/ptmx", os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		return nil, nil, ErrPTY.Make().Cause(err)
+	}
+
+	var n uint32
+	var unlock int32
+	if err := control(master, func(fd uintptr) error {
+		if err := ioctl(fd, syscall.TIOCSPTLCK, uintptr(unsafe.Pointer(&unlock))); err != nil {
+			return err
+		}
+		return ioctl(fd, syscall.TIOCGPTN, uintptr(unsafe.Pointer(&n)))
+	}); err != nil {
+		master.Close()
+		return nil, nil, ErrPTY.Make().Cause(err)
+	}
+
+	tty, err := os.OpenFile("/dev/pts/"+strconv.FormatUint(uint64(n), 10), os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		master.Close()
+		return nil, nil, ErrPTY.Make().Cause(err)
+	}
+
+	size := windowSize{Rows: rows, Cols: cols}
+	if err := control(tty, func(fd uintptr) error {
+		return ioctl(fd, syscall.TIOCSWINSZ, uintptr(unsafe.Pointer(&size)))
+	}); err != nil {
+		tty.Close()
+		master.Close()
+		return nil, nil, ErrPTY.Make().Cause(err)
+	}
+	return master, tty, nil
+}
+
+// control calls f with the raw descriptor of file without switching it to blocking mode.
+func control(file *os.File, f func(fd uintptr) error) error {
+	conn, err := file.SyscallConn()
+	if err != nil {
+		return err
+	}
+	var ctrlErr error
+	if err := conn.Control(func(fd uintptr) {
+		ctrlErr = f(fd)
+	}); err != nil {
+		return err
+	}
+	return ctrlErr
+}
+
+func ioctl(fd, req, arg uintptr) error {
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, req, arg); errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// setControllingTerminal runs the command in a new session with stdin as controlling terminal.
+func setControllingTerminal(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	// a new session implies a new process group, which cannot be requested additionally
+	cmd.SysProcAttr.Setpgid = false
+	cmd.SysProcAttr.Setsid = true
+	cmd.SysProcAttr.Setctty = true
+	cmd.SysProcAttr.Ctty = 0
+}
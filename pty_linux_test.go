@@ -0,0 +1,44 @@
+//go:build linux
+// +build linux
+
+package exec
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sbreitf1/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+/* ############################################# */
+/* ###                RunPTY                 ### */
+/* ############################################# */
+
+func TestRunPTY(t *testing.T) {
+	out, code, err := NewLocalExecutor().RunPTY("sh", "-c", "test -t 0 && test -t 1 && echo terminal; stty size; exit 3")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, code)
+	assert.Equal(t, "terminal\r\n24 80\r\n", out)
+}
+
+func TestRunPTYSize(t *testing.T) {
+	out, code, err := NewLocalExecutor(WithPTYSize(120, 40), WithProcessGroup()).RunPTY("stty", "size")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "40 120\r\n", out)
+}
+
+func TestRunPTYError(t *testing.T) {
+	_, _, err := NewLocalExecutor().RunPTY(path("noexec.txt"))
+	assert.True(t, errors.InstanceOf(err, ErrPermissionDenied))
+}
+
+func TestRunPTYTimeout(t *testing.T) {
+	start := time.Now()
+	out, _, err := NewLocalExecutor(WithTimeout(200 * time.Millisecond)).RunPTY(path("signal.sh"))
+	assert.True(t, errors.InstanceOf(err, ErrTimeout))
+	assert.True(t, strings.Contains(out, "ready"))
+	assert.True(t, time.Since(start) < 5*time.Second)
+}
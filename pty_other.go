@@ -0,0 +1,20 @@
+//go:build !linux
+// +build !linux
+
+package exec
+
+import (
+	"os"
+	"os/exec"
+
+	"github.com/sbreitf1/errors"
+)
+
+// openPTY always fails because pseudo terminals are only implemented for Linux. This includes macOS and the BSDs, which allocate pseudo terminals differently.
+func openPTY(cols, rows uint16) (*os.File, *os.File, errors.Error) {
+	return nil, nil, ErrPTY.Make().StrCause("Pseudo terminals are only supported on Linux")
+}
+
+// setControllingTerminal is not supported on this platform and leaves the command unchanged.
+func setControllingTerminal(cmd *exec.Cmd) {
+}
@@ -0,0 +1,21 @@
+//go:build !linux
+// +build !linux
+
+package exec
+
+import (
+	"testing"
+
+	"github.com/sbreitf1/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+/* ############################################# */
+/* ###                RunPTY                 ### */
+/* ############################################# */
+
+func TestRunPTYUnsupported(t *testing.T) {
+	out, _, err := NewLocalExecutor().RunPTY(path("success.sh"))
+	assert.True(t, errors.InstanceOf(err, ErrPTY))
+	assert.Equal(t, "", out)
+}
@@ -0,0 +1,68 @@
+package exec
+
+import (
+	"strings"
+
+	"github.com/sbreitf1/errors"
+)
+
+// RawToken denotes a single word of a command line together with its original representation.
+type RawToken struct {
+	// Value denotes the decoded word as returned by Parse.
+	Value string
+	// Raw denotes the word exactly as written in the command line, including all quotes and escape characters.
+	Raw string
+	// Offset denotes the byte offset of Raw in the command line.
+	Offset int
+	// Styles denotes the quoting styles used in the word in order of appearance. StyleRaw denotes escape characters outside of quotes. Styles is empty for words without quotes and escape characters.
+	Styles []QuoteStyle
+}
+
+// WithValue returns a token for value that is quoted like the original token if it consists of a single quoting style, and using Quote otherwise. This allows to change a single word while keeping the quoting of all others.
+func (t RawToken) WithValue(value string) RawToken {
+	style := StyleAuto
+	if len(t.Styles) == 1 {
+		style = t.Styles[0]
+	}
+
+	raw := style.Quote(value)
+	token := RawToken{Value: value, Raw: raw, Offset: t.Offset}
+	if tokens, err := ParseRaw(raw); err == nil && len(tokens) == 1 {
+		token.Styles = tokens[0].Styles
+	}
+	return token
+}
+
+// ParseRaw splits a command line into words like Parse, but additionally returns the original representation of every word. Joining the Raw values of all tokens using JoinRawTokens yields a command line that is parsed to the same command and arguments, with whitespace and line continuations between words normalized to single spaces.
+func ParseRaw(commandLine string) ([]RawToken, errors.Error) {
+	tokens, err := tokenize(commandLine, DefaultParseConfig(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, newParseError(ParseErrorEmptyInput, "Unexpected end of command line")
+	}
+
+	// byte offsets of all runes in the same way tokenize converts the command line to runes
+	offsets := make([]int, 0, len(commandLine)+1)
+	for i := range commandLine {
+		offsets = append(offsets, i)
+	}
+	offsets = append(offsets, len(commandLine))
+
+	rawTokens := make([]RawToken, len(tokens))
+	for i, t := range tokens {
+		start, end := offsets[t.start], offsets[t.end]
+		rawTokens[i] = RawToken{Value: t.value, Raw: commandLine[start:end], Offset: start, Styles: t.styles}
+	}
+	return rawTokens, nil
+}
+
+// JoinRawTokens assembles a command line from the original representation of the given tokens separated by single spaces.
+func JoinRawTokens(tokens []RawToken) string {
+	raw := make([]string, len(tokens))
+	for i, t := range tokens {
+		raw[i] = t.Raw
+	}
+	return strings.Join(raw, " ")
+}
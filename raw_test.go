@@ -0,0 +1,89 @@
+package exec
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+/* ############################################# */
+/* ###               ParseRaw                ### */
+/* ############################################# */
+
+func TestParseRaw(t *testing.T) {
+	tokens, err := ParseRaw(`git  commit -m "fix: it's done" --author='A B' foo\ bar $'a\tb' x"y"'z'`)
+	assert.NoError(t, err)
+	assert.Equal(t, []RawToken{
+		{Value: "git", Raw: "git", Offset: 0},
+		{Value: "commit", Raw: "commit", Offset: 5},
+		{Value: "-m", Raw: "-m", Offset: 12},
+		{Value: "fix: it's done", Raw: `"fix: it's done"`, Offset: 15, Styles: []QuoteStyle{StyleDouble}},
+		{Value: "--author=A B", Raw: `--author='A B'`, Offset: 32, Styles: []QuoteStyle{StyleSingle}},
+		{Value: "foo bar", Raw: `foo\ bar`, Offset: 47, Styles: []QuoteStyle{StyleRaw}},
+		{Value: "a\tb", Raw: `$'a\tb'`, Offset: 56, Styles: []QuoteStyle{StyleANSIC}},
+		{Value: "xyz", Raw: `x"y"'z'`, Offset: 64, Styles: []QuoteStyle{StyleDouble, StyleSingle}},
+	}, tokens)
+}
+
+func TestParseRawOffsets(t *testing.T) {
+	commandLine := "echo  äöü \"€ x\"\t'\\'"
+	tokens, err := ParseRaw(commandLine)
+	assert.NoError(t, err)
+	for _, token := range tokens {
+		assert.Equal(t, token.Raw, commandLine[token.Offset:token.Offset+len(token.Raw)])
+	}
+	assert.Equal(t, []string{"echo", "äöü", "€ x", `\`}, rawValues(tokens))
+}
+
+func TestParseRawContinuation(t *testing.T) {
+	tokens, err := ParseRaw("echo foo\\\nbar \\\n baz")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"echo", "foobar", "baz"}, rawValues(tokens))
+	assert.Equal(t, "foo\\\nbar", tokens[1].Raw)
+	assert.Equal(t, "baz", tokens[2].Raw)
+}
+
+func TestParseRawErrors(t *testing.T) {
+	_, err := ParseRaw(``)
+	assert.Equal(t, ParseErrorEmptyInput, ParseErrorKindOf(err))
+	_, err = ParseRaw(`echo "unterminated`)
+	assert.Equal(t, ParseErrorUnterminatedDoubleQuote, ParseErrorKindOf(err))
+}
+
+func TestJoinRawTokens(t *testing.T) {
+	commandLine := `cp  "my file.txt" 'dest dir/'   --backup=numbered`
+	tokens, err := ParseRaw(commandLine)
+	assert.NoError(t, err)
+	assert.Equal(t, `cp "my file.txt" 'dest dir/' --backup=numbered`, JoinRawTokens(tokens))
+
+	// only the edited token is requoted
+	tokens[2] = tokens[2].WithValue("other dir/")
+	assert.Equal(t, `cp "my file.txt" 'other dir/' --backup=numbered`, JoinRawTokens(tokens))
+	tokens[1] = tokens[1].WithValue(`say "hi"`)
+	assert.Equal(t, `cp "say \"hi\"" 'other dir/' --backup=numbered`, JoinRawTokens(tokens))
+	tokens[3] = tokens[3].WithValue("--backup=simple none")
+	assert.Equal(t, `cp "say \"hi\"" 'other dir/' `+Quote("--backup=simple none"), JoinRawTokens(tokens))
+
+	command, args, err := Parse(JoinRawTokens(tokens))
+	assert.NoError(t, err)
+	assert.Equal(t, "cp", command)
+	assert.Equal(t, []string{`say "hi"`, "other dir/", "--backup=simple none"}, args)
+}
+
+func TestRawTokenWithValueStyles(t *testing.T) {
+	token := RawToken{Raw: "x", Value: "x"}.WithValue("a b")
+	assert.Equal(t, Quote("a b"), token.Raw)
+	assert.NotEmpty(t, token.Styles)
+
+	token = RawToken{Raw: `x"y"'z'`, Value: "xyz", Styles: []QuoteStyle{StyleDouble, StyleSingle}}.WithValue("plain")
+	assert.Equal(t, "plain", token.Raw)
+	assert.Empty(t, token.Styles)
+}
+
+func rawValues(tokens []RawToken) []string {
+	values := make([]string, len(tokens))
+	for i, token := range tokens {
+		values[i] = token.Value
+	}
+	return values
+}
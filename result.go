@@ -0,0 +1,77 @@
+package exec
+
+import (
+	"encoding/json"
+	"os"
+	"syscall"
+	"time"
+
+	"github.com/sbreitf1/errors"
+)
+
+// CommandSpec describes a command with its arguments.
+type CommandSpec struct {
+	Command string
+	Args    []string
+}
+
+// RunResult holds the outcome of a single command execution.
+type RunResult struct {
+	Output   string
+	ExitCode int
+	Err      errors.Error
+	Duration time.Duration
+	// OutputBytes denotes the number of bytes produced by the process, which might exceed the length of Output for limited captures.
+	OutputBytes int
+	// Truncated is set when output has been dropped due to WithMaxOutput or WithTailBuffer, or after the match of RunAwait, so Output is incomplete.
+	Truncated bool
+	// EscalatedToKill is set when the process ignored the graceful shutdown signal and had to be killed.
+	EscalatedToKill bool
+	// Signal denotes the signal that terminated the process, nil if the process exited on its own. ExitCode is 128 plus the signal number in this case like in shells.
+	Signal os.Signal
+	// Stdout and Stderr hold the separately captured streams and are only set by ExecuteSplit.
+	Stdout string
+	Stderr string
+	// StdoutTruncated and StderrTruncated are set when the respective stream has been limited by WithMaxStdout or WithMaxStderr.
+	StdoutTruncated bool
+	StderrTruncated bool
+}
+
+// runResultJSON denotes the JSON layout of RunResult.
+type runResultJSON struct {
+	Output          string `json:"output"`
+	ExitCode        int    `json:"exit_code"`
+	Error           string `json:"error,omitempty"`
+	DurationMillis  int64  `json:"duration_ms"`
+	OutputBytes     int    `json:"output_bytes"`
+	Truncated       bool   `json:"truncated"`
+	EscalatedToKill bool   `json:"escalated_to_kill"`
+	Signal          int    `json:"signal,omitempty"`
+	Stdout          string `json:"stdout,omitempty"`
+	Stderr          string `json:"stderr,omitempty"`
+	StdoutTruncated bool   `json:"stdout_truncated,omitempty"`
+	StderrTruncated bool   `json:"stderr_truncated,omitempty"`
+}
+
+// MarshalJSON encodes the result for machine-readable reports. The duration is given in milliseconds, the error as message string and the terminating signal as number. Error, signal and the separate streams of ExecuteSplit are omitted if not set.
+func (r RunResult) MarshalJSON() ([]byte, error) {
+	v := runResultJSON{
+		Output:          r.Output,
+		ExitCode:        r.ExitCode,
+		DurationMillis:  r.Duration.Milliseconds(),
+		OutputBytes:     r.OutputBytes,
+		Truncated:       r.Truncated,
+		EscalatedToKill: r.EscalatedToKill,
+		Stdout:          r.Stdout,
+		Stderr:          r.Stderr,
+		StdoutTruncated: r.StdoutTruncated,
+		StderrTruncated: r.StderrTruncated,
+	}
+	if r.Err != nil {
+		v.Error = r.Err.Error()
+	}
+	if s, ok := r.Signal.(syscall.Signal); ok {
+		v.Signal = int(s)
+	}
+	return json.Marshal(v)
+}
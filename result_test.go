@@ -0,0 +1,32 @@
+package exec
+
+import (
+	"encoding/json"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+/* ############################################# */
+/* ###            RunResult JSON             ### */
+/* ############################################# */
+
+func TestRunResultJSON(t *testing.T) {
+	data, err := json.Marshal(RunResult{Output: "foo\n", ExitCode: 3, Duration: 1500 * time.Millisecond, OutputBytes: 10, Truncated: true})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"output":"foo\n","exit_code":3,"duration_ms":1500,"output_bytes":10,"truncated":true,"escalated_to_kill":false}`, string(data))
+}
+
+func TestRunResultJSONError(t *testing.T) {
+	data, err := json.Marshal([]RunResult{{Err: ErrCommandNotFound.Args("foo").Make()}})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `[{"output":"","exit_code":0,"error":"Command \"foo\" not found","duration_ms":0,"output_bytes":0,"truncated":false,"escalated_to_kill":false}]`, string(data))
+}
+
+func TestRunResultJSONSignal(t *testing.T) {
+	data, err := json.Marshal(RunResult{ExitCode: 137, Signal: syscall.SIGKILL})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"output":"","exit_code":137,"duration_ms":0,"output_bytes":0,"truncated":false,"escalated_to_kill":false,"signal":9}`, string(data))
+}
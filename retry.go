@@ -0,0 +1,96 @@
+package exec
+
+import (
+	"regexp"
+	"time"
+
+	"github.com/sbreitf1/errors"
+)
+
+// RetryExecutor runs commands on an inner Executor and repeats them when they fail or return a non-zero exit code.
+type RetryExecutor struct {
+	inner    Executor
+	attempts int
+	backoff  time.Duration
+	progress *regexp.Regexp
+}
+
+// RetryOption denotes a configuration option for a RetryExecutor.
+type RetryOption func(e *RetryExecutor)
+
+// WithRetryBackoff waits for d before the first retry. The delay is doubled for every further retry.
+func WithRetryBackoff(d time.Duration) RetryOption {
+	return func(e *RetryExecutor) {
+		e.backoff = d
+	}
+}
+
+// WithRetryProgress resets the remaining attempts and the backoff whenever the output of a failed attempt matches pattern. This allows resumable commands to retry as long as they make progress.
+func WithRetryProgress(pattern *regexp.Regexp) RetryOption {
+	return func(e *RetryExecutor) {
+		e.progress = pattern
+	}
+}
+
+// RunLine runs the command line on the inner executor until it succeeds or all attempts are used up. Parse errors are not retried.
+func (e *RetryExecutor) RunLine(commandLine string) (output string, code int, err errors.Error) {
+	e.retry(func() (bool, bool) {
+		output, code, err = e.inner.RunLine(commandLine)
+		if err != nil && errors.InstanceOf(err, ErrParse) {
+			return true, false
+		}
+		return err == nil && code == 0, e.progress != nil && e.progress.MatchString(output)
+	})
+	return
+}
+
+// Run executes the command until it succeeds or all attempts are used up. The result of the last attempt is returned.
+func (e *RetryExecutor) Run(command string, args ...string) (output string, code int, err errors.Error) {
+	e.retry(func() (bool, bool) {
+		output, code, err = e.inner.Run(command, args...)
+		return err == nil && code == 0, e.progress != nil && e.progress.MatchString(output)
+	})
+	return
+}
+
+// RunBytes executes the command until it succeeds or all attempts are used up. The result of the last attempt is returned.
+func (e *RetryExecutor) RunBytes(command string, args ...string) (output []byte, code int, err errors.Error) {
+	e.retry(func() (bool, bool) {
+		output, code, err = e.inner.RunBytes(command, args...)
+		return err == nil && code == 0, e.progress != nil && e.progress.Match(output)
+	})
+	return
+}
+
+// retry calls attempt until it reports to be done or all attempts are used up.
+func (e *RetryExecutor) retry(attempt func() (done, progress bool)) {
+	remaining := e.attempts
+	backoff := e.backoff
+	for {
+		done, progress := attempt()
+		if done {
+			return
+		}
+
+		remaining--
+		if progress {
+			remaining = e.attempts
+			backoff = e.backoff
+		}
+		if remaining <= 0 {
+			return
+		}
+
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// NewRetryExecutor returns an executor that runs every command up to attempts times on inner until it succeeds.
+func NewRetryExecutor(inner Executor, attempts int, options ...RetryOption) *RetryExecutor {
+	e := &RetryExecutor{inner: inner, attempts: attempts}
+	for _, option := range options {
+		option(e)
+	}
+	return e
+}
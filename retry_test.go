@@ -0,0 +1,87 @@
+package exec
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/sbreitf1/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+/* ############################################# */
+/* ###             RetryExecutor             ### */
+/* ############################################# */
+
+func TestRetryExecutorSuccess(t *testing.T) {
+	calls := 0
+	e := NewRetryExecutor(NewMockExecutor(func(command string, args ...string) (string, int, errors.Error) {
+		calls++
+		if calls < 3 {
+			return "failed", 1, nil
+		}
+		return "done", 0, nil
+	}), 3)
+
+	out, code, err := e.Run("newcommand")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "done", out)
+	assert.Equal(t, 3, calls)
+}
+
+func TestRetryExecutorExhausted(t *testing.T) {
+	calls := 0
+	e := NewRetryExecutor(NewMockExecutor(func(command string, args ...string) (string, int, errors.Error) {
+		calls++
+		return "failed", 0, ErrRun.Make()
+	}), 3, WithRetryBackoff(time.Millisecond))
+
+	out, _, err := e.Run("newcommand")
+	assert.True(t, errors.InstanceOf(err, ErrRun))
+	assert.Equal(t, "failed", out)
+	assert.Equal(t, 3, calls)
+}
+
+func TestRetryExecutorProgress(t *testing.T) {
+	calls := 0
+	e := NewRetryExecutor(NewMockExecutor(func(command string, args ...string) (string, int, errors.Error) {
+		calls++
+		switch {
+		case calls == 8:
+			return "done", 0, nil
+		case calls%2 == 0:
+			return "progress 10%", 1, nil
+		default:
+			return "stalled", 1, nil
+		}
+	}), 2, WithRetryProgress(regexp.MustCompile(`progress \d+%`)))
+
+	out, code, err := e.Run("newcommand")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "done", out)
+	assert.Equal(t, 8, calls)
+}
+
+func TestRetryExecutorNoProgress(t *testing.T) {
+	calls := 0
+	e := NewRetryExecutor(NewMockExecutor(func(command string, args ...string) (string, int, errors.Error) {
+		calls++
+		return "stalled", 1, nil
+	}), 2, WithRetryProgress(regexp.MustCompile(`progress \d+%`)))
+
+	_, code, err := e.Run("newcommand")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, code)
+	assert.Equal(t, 2, calls)
+}
+
+func TestRetryExecutorRunLineParseError(t *testing.T) {
+	e := NewRetryExecutor(NewMockExecutor(func(command string, args ...string) (string, int, errors.Error) {
+		assert.Fail(t, "Callback should not be executed on parse fail")
+		return "", 0, nil
+	}), 3)
+	_, _, err := e.RunLine(`newcommand "test`)
+	assert.True(t, errors.InstanceOf(err, ErrParse))
+}
@@ -0,0 +1,46 @@
+package exec
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/sbreitf1/errors"
+)
+
+const (
+	// maxErrorOutput denotes the maximum number of output bytes included in the message of a ReturnCodeError.
+	maxErrorOutput = 512
+)
+
+// baseError allows to embed errors.Error without conflicting with the Error method.
+type baseError = errors.Error
+
+// ReturnCodeError is returned as ErrReturnCode by ShouldRun, ShouldRunLine and StrictExecutor. It carries the exit code and the complete output of the failed command, and its message includes the end of the output.
+type ReturnCodeError struct {
+	baseError
+	Output   string
+	ExitCode int
+}
+
+// newReturnCodeError returns an ErrReturnCode error for code including the tail of output in its message.
+func newReturnCodeError(code int, output string) errors.Error {
+	err := ErrReturnCode.Args(code).Make()
+	if tail := outputTail(output, maxErrorOutput); len(tail) > 0 {
+		err = err.StrCause("%s", tail)
+	}
+	return &ReturnCodeError{baseError: err, Output: output, ExitCode: code}
+}
+
+// outputTail returns at most the last max bytes of the trimmed output without splitting UTF-8 sequences.
+func outputTail(output string, max int) string {
+	output = strings.TrimSpace(output)
+	if len(output) <= max {
+		return output
+	}
+
+	start := len(output) - max
+	for start < len(output) && !utf8.RuneStart(output[start]) {
+		start++
+	}
+	return "..." + output[start:]
+}
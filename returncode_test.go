@@ -0,0 +1,44 @@
+package exec
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sbreitf1/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+/* ############################################# */
+/* ###            ReturnCodeError            ### */
+/* ############################################# */
+
+func TestShouldRunReturnCodeError(t *testing.T) {
+	_, err := ShouldRun(path("fail.sh"))
+	assert.True(t, errors.InstanceOf(err, ErrReturnCode))
+	assert.Equal(t, KindExit, ErrorKind(err))
+	assert.Equal(t, "Process returned with code 1: error output", err.Error())
+
+	rcErr, ok := err.(*ReturnCodeError)
+	assert.True(t, ok)
+	assert.Equal(t, 1, rcErr.ExitCode)
+	assert.Equal(t, "error output\n", rcErr.Output)
+}
+
+func TestStrictExecutorReturnCodeError(t *testing.T) {
+	e := NewStrictExecutor(NewMockExecutor(func(command string, args ...string) (string, int, errors.Error) {
+		return "", 3, nil
+	}))
+	_, _, err := e.Run("foo")
+	assert.Equal(t, "Process returned with code 3", err.Error())
+	assert.Equal(t, 3, err.(*ReturnCodeError).ExitCode)
+}
+
+func TestReturnCodeErrorTruncated(t *testing.T) {
+	output := strings.Repeat("ä", maxErrorOutput) + "the end\n"
+	err := newReturnCodeError(2, output)
+	msg := err.Error()
+	assert.True(t, strings.HasPrefix(msg, "Process returned with code 2: ..."))
+	assert.True(t, strings.HasSuffix(msg, "äthe end"))
+	assert.True(t, len(msg) <= len("Process returned with code 2: ...")+maxErrorOutput)
+	assert.Equal(t, output, err.(*ReturnCodeError).Output)
+}
@@ -0,0 +1,116 @@
+package exec
+
+import (
+	"math/rand"
+	"testing"
+	"testing/quick"
+
+	"github.com/stretchr/testify/assert"
+)
+
+/* ############################################# */
+/* ###              Round Trip               ### */
+/* ############################################# */
+
+// roundTripAlphabet contains all runes that are treated specially by Quote or Parse.
+var roundTripAlphabet = []rune{'a', 'Z', '0', ' ', '\t', '\n', '\r', '\v', '\f', '\000', '\a', '\x7f', '\u0085', ' ', ' ', '\'', '"', '\\', '$', ';', '|', '&', '<', '>', 'ä', '€', '😀'}
+
+func TestRoundTripQuick(t *testing.T) {
+	f := func(command string, args []string) bool {
+		return checkRoundTrip(t, command, args)
+	}
+	assert.NoError(t, quick.Check(f, &quick.Config{MaxCount: 1000}))
+}
+
+func TestRoundTripVerboseQuick(t *testing.T) {
+	f := func(command string, args []string) bool {
+		return checkRoundTripWith(t, GetCommandLineVerbose, command, args)
+	}
+	assert.NoError(t, quick.Check(f, &quick.Config{MaxCount: 1000}))
+}
+
+func TestRoundTripAlphabet(t *testing.T) {
+	rnd := rand.New(rand.NewSource(42))
+	randomString := func() string {
+		runes := make([]rune, rnd.Intn(8))
+		for i := range runes {
+			runes[i] = roundTripAlphabet[rnd.Intn(len(roundTripAlphabet))]
+		}
+		return string(runes)
+	}
+
+	for i := 0; i < 5000; i++ {
+		args := make([]string, rnd.Intn(4))
+		for j := range args {
+			args[j] = randomString()
+		}
+		command := randomString()
+		if !checkRoundTrip(t, command, args) || !checkRoundTripWith(t, GetCommandLineVerbose, command, args) {
+			return
+		}
+	}
+}
+
+func TestRoundTripEdgeCases(t *testing.T) {
+	for _, str := range []string{"x", "", " ", "''", `""`, `\`, `$'`, `$'x'`, "\000", "a\nb", `'\''`, `"\"`} {
+		checkRoundTrip(t, str, []string{str})
+		checkRoundTrip(t, "x", []string{str, str})
+	}
+}
+
+func TestRoundTripEmptyArgs(t *testing.T) {
+	for _, args := range [][]string{
+		{"", "a", "b"},
+		{"a", "", "b"},
+		{"a", "b", ""},
+		{"", "", ""},
+		{"", "a b", "", "it's", ""},
+	} {
+		checkRoundTrip(t, "x", args)
+		for _, style := range []QuoteStyle{StyleRaw, StyleSingle, StyleDouble, StyleANSIC, StyleReadable} {
+			cmd, parsed, err := Parse(GetCommandLineStyle(style, "x", args...))
+			assert.NoError(t, err)
+			assert.Equal(t, "x", cmd)
+			assert.Equal(t, args, parsed, "style %d", style)
+		}
+	}
+}
+
+func TestRoundTripLineBreaks(t *testing.T) {
+	args := []string{"a\nb", "\r\n", "\\\n", "x\\"}
+	for _, style := range []QuoteStyle{StyleAuto, StyleRaw, StyleSingle, StyleDouble, StyleANSIC, StyleReadable} {
+		_, parsed, err := Parse(GetCommandLineStyle(style, "x", args...))
+		assert.NoError(t, err)
+		assert.Equal(t, args, parsed, "style %d", style)
+	}
+}
+
+func TestParseEmptyArgsAdjacentQuotes(t *testing.T) {
+	cmd, args, err := Parse(`x "" ''"" $'' a""b ""`)
+	assert.NoError(t, err)
+	assert.Equal(t, "x", cmd)
+	assert.Equal(t, []string{"", "", "", "ab", ""}, args)
+}
+
+func TestRoundTripInvalidUTF8(t *testing.T) {
+	// documented exception: invalid byte sequences are replaced by U+FFFD
+	cmd, args, err := Parse(GetCommandLine("x", "a\xffb"))
+	assert.NoError(t, err)
+	assert.Equal(t, "x", cmd)
+	assert.Equal(t, []string{"a�b"}, args)
+}
+
+func checkRoundTrip(t *testing.T, command string, args []string) bool {
+	return checkRoundTripWith(t, GetCommandLine, command, args)
+}
+
+func checkRoundTripWith(t *testing.T, getCommandLine func(string, ...string) string, command string, args []string) bool {
+	if len(args) == 0 {
+		args = nil
+	}
+	commandLine := getCommandLine(command, args...)
+	parsedCommand, parsedArgs, err := Parse(commandLine)
+	return assert.NoError(t, err, "command line %q", commandLine) &&
+		assert.Equal(t, command, parsedCommand, "command line %q", commandLine) &&
+		assert.Equal(t, args, parsedArgs, "command line %q", commandLine)
+}
@@ -0,0 +1,77 @@
+package exec
+
+import (
+	"context"
+
+	"github.com/sbreitf1/errors"
+)
+
+const (
+	opSequence = ";"
+)
+
+// SequenceOption denotes a configuration option for RunLineAll.
+type SequenceOption func(c *sequenceConfig)
+
+type sequenceConfig struct {
+	continueOnFailure bool
+}
+
+// WithContinueOnFailure runs all commands of a sequence, even if a previous command has failed.
+func WithContinueOnFailure() SequenceOption {
+	return func(c *sequenceConfig) {
+		c.continueOnFailure = true
+	}
+}
+
+// ParseSequence splits a command line at unquoted semicolons and returns the command and arguments of every command in order. Quoted or escaped semicolons are kept literally and empty commands caused by leading, trailing or doubled semicolons are skipped.
+func ParseSequence(commandLine string) ([][]string, errors.Error) {
+	tokens, err := tokenize(commandLine, DefaultParseConfig(), []string{opSequence})
+	if err != nil {
+		return nil, err
+	}
+
+	commands := make([][]string, 0)
+	current := make([]string, 0)
+	for _, t := range tokens {
+		if t.operator {
+			if len(current) > 0 {
+				commands = append(commands, current)
+				current = make([]string, 0)
+			}
+			continue
+		}
+		current = append(current, t.value)
+	}
+	if len(current) > 0 {
+		commands = append(commands, current)
+	}
+
+	if len(commands) == 0 {
+		return nil, newParseError(ParseErrorEmptyInput, "Unexpected end of command line")
+	}
+	return commands, nil
+}
+
+// RunLineAll parses a command line with commands separated by semicolons and runs them in order. Execution stops after the first command that fails or returns a non-zero exit code unless WithContinueOnFailure is used. The results of all executed commands are returned, the returned error is only set for malformed command lines. Only sequencing with ";" is supported, "&&" and "||" are not interpreted.
+func (e *LocalExecutor) RunLineAll(commandLine string, options ...SequenceOption) ([]RunResult, errors.Error) {
+	var config sequenceConfig
+	for _, option := range options {
+		option(&config)
+	}
+
+	commands, err := ParseSequence(commandLine)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]RunResult, 0, len(commands))
+	for _, command := range commands {
+		result := e.Execute(context.Background(), command[0], command[1:]...)
+		results = append(results, result)
+		if !config.continueOnFailure && (result.Err != nil || result.ExitCode != 0) {
+			break
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,99 @@
+package exec
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sbreitf1/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+/* ############################################# */
+/* ###             ParseSequence             ### */
+/* ############################################# */
+
+func TestParseSequence(t *testing.T) {
+	commands, err := ParseSequence(`cd /tmp; ls -la`)
+	assert.NoError(t, err)
+	assert.Equal(t, [][]string{{"cd", "/tmp"}, {"ls", "-la"}}, commands)
+}
+
+func TestParseSequenceNoSpaces(t *testing.T) {
+	commands, err := ParseSequence(`a;b c;d`)
+	assert.NoError(t, err)
+	assert.Equal(t, [][]string{{"a"}, {"b", "c"}, {"d"}}, commands)
+}
+
+func TestParseSequenceSingle(t *testing.T) {
+	commands, err := ParseSequence(`newcommand -d "foo bar"`)
+	assert.NoError(t, err)
+	assert.Equal(t, [][]string{{"newcommand", "-d", "foo bar"}}, commands)
+}
+
+func TestParseSequenceEmptyCommands(t *testing.T) {
+	commands, err := ParseSequence(`; a ;; b;  ;`)
+	assert.NoError(t, err)
+	assert.Equal(t, [][]string{{"a"}, {"b"}}, commands)
+}
+
+func TestParseSequenceQuoted(t *testing.T) {
+	commands, err := ParseSequence(`echo "a;b" 'c;d' e\;f $'g;h'; echo "" ;`)
+	assert.NoError(t, err)
+	assert.Equal(t, [][]string{{"echo", "a;b", "c;d", "e;f", "g;h"}, {"echo", ""}}, commands)
+}
+
+func TestParseSequenceRoundTrip(t *testing.T) {
+	commands, err := ParseSequence(GetCommandLine("echo", "a;b"))
+	assert.NoError(t, err)
+	assert.Equal(t, [][]string{{"echo", "a;b"}}, commands)
+
+	args := []string{";", "a;b", ";;", "x; rm -rf /"}
+	for _, style := range []QuoteStyle{StyleAuto, StyleRaw, StyleReadable} {
+		commands, err := ParseSequence(GetCommandLineStyle(style, "echo", args...))
+		assert.NoError(t, err)
+		assert.Equal(t, [][]string{append([]string{"echo"}, args...)}, commands)
+	}
+}
+
+func TestParseSequenceEmpty(t *testing.T) {
+	_, err := ParseSequence(` ; ;`)
+	assert.True(t, errors.InstanceOf(err, ErrParse))
+}
+
+func TestParseSequenceError(t *testing.T) {
+	_, err := ParseSequence(`echo "a; b`)
+	assert.True(t, errors.InstanceOf(err, ErrParse))
+}
+
+/* ############################################# */
+/* ###              RunLineAll               ### */
+/* ############################################# */
+
+func TestRunLineAll(t *testing.T) {
+	line := Quote(path("args.sh")) + " a b; " + Quote(path("success.sh")) + "; " + Quote(path("args.sh")) + ` "c;d"`
+	results, err := NewLocalExecutor().RunLineAll(line)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(results))
+	assert.True(t, strings.Contains(results[0].Output, "1a ; 2b"))
+	assert.True(t, strings.Contains(results[1].Output, "some test output here"))
+	assert.True(t, strings.Contains(results[2].Output, "1c;d ; 2"))
+}
+
+func TestRunLineAllStopOnFailure(t *testing.T) {
+	line := Quote(path("success.sh")) + "; " + Quote(path("fail.sh")) + "; " + Quote(path("success.sh"))
+	results, err := NewLocalExecutor().RunLineAll(line)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(results))
+	assert.Equal(t, 1, results[1].ExitCode)
+
+	results, err = NewLocalExecutor().RunLineAll(line, WithContinueOnFailure())
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(results))
+	assert.Equal(t, 0, results[2].ExitCode)
+}
+
+func TestRunLineAllParseError(t *testing.T) {
+	results, err := NewLocalExecutor().RunLineAll(`echo "a; b`)
+	assert.True(t, errors.InstanceOf(err, ErrParse))
+	assert.Nil(t, results)
+}
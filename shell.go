@@ -0,0 +1,31 @@
+package exec
+
+import (
+	"github.com/sbreitf1/errors"
+)
+
+// ShellExecutor passes command lines to the platform shell (sh -c on Unix, cmd /c on Windows) instead of parsing them. This enables shell features like pipes, globs and variable expansion, but also makes the command line subject to shell injection. Use LocalExecutor for safe argument handling.
+type ShellExecutor struct {
+	local *LocalExecutor
+}
+
+// RunLine executes the command line using the platform shell and returns the exit code of the shell.
+func (e *ShellExecutor) RunLine(commandLine string) (string, int, errors.Error) {
+	command, args := shellCommand(commandLine)
+	return e.local.Run(command, args...)
+}
+
+// Run executes a command with separated arguments directly without involving the shell.
+func (e *ShellExecutor) Run(command string, args ...string) (string, int, errors.Error) {
+	return e.local.Run(command, args...)
+}
+
+// RunBytes executes a command with separated arguments directly without involving the shell and returns the unmodified output bytes.
+func (e *ShellExecutor) RunBytes(command string, args ...string) ([]byte, int, errors.Error) {
+	return e.local.RunBytes(command, args...)
+}
+
+// NewShellExecutor returns an executor that runs command lines using the platform shell. The options are applied to the underlying LocalExecutor.
+func NewShellExecutor(options ...LocalOption) *ShellExecutor {
+	return &ShellExecutor{NewLocalExecutor(options...)}
+}
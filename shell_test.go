@@ -0,0 +1,105 @@
+package exec
+
+import (
+	"context"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sbreitf1/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+/* ############################################# */
+/* ###             ShellExecutor             ### */
+/* ############################################# */
+
+func TestShellExecutorRunLine(t *testing.T) {
+	e := NewShellExecutor()
+	out, code, err := e.RunLine(`echo "foo bar" | tr a-z A-Z; echo $((1+2))`)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "FOO BAR\n3\n", out)
+}
+
+func TestShellExecutorExitCode(t *testing.T) {
+	e := NewShellExecutor()
+	_, code, err := e.RunLine(`exit 42`)
+	assert.NoError(t, err)
+	assert.Equal(t, 42, code)
+
+	out, code, err := e.RunLine(Quote(path("fail.sh")) + " && echo unreachable")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, code)
+	assert.False(t, strings.Contains(out, "unreachable"))
+}
+
+func TestShellExecutorRun(t *testing.T) {
+	e := NewShellExecutor()
+	out, code, err := e.Run(path("args.sh"), "$HOME", "a b")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, code)
+	assert.True(t, strings.Contains(out, "1$HOME ; 2a b"))
+}
+
+func TestShellExecutorOptions(t *testing.T) {
+	e := NewShellExecutor(WithTimeout(200*time.Millisecond), WithProcessGroup())
+	_, _, err := e.RunLine(`sleep 5`)
+	assert.True(t, errors.InstanceOf(err, ErrTimeout))
+}
+
+func TestShellExecutorDecorated(t *testing.T) {
+	shell := NewShellExecutor()
+	local := NewLocalExecutor(WithEnvAssignments())
+	decorators := map[string]func(inner Executor) Executor{
+		"tee":       func(inner Executor) Executor { return NewTeeExecutor(inner, ioutil.Discard) },
+		"retry":     func(inner Executor) Executor { return NewRetryExecutor(inner, 2) },
+		"strict":    func(inner Executor) Executor { return NewStrictExecutor(inner) },
+		"context":   func(inner Executor) Executor { return NewContextExecutor(context.Background(), inner) },
+		"cache":     func(inner Executor) Executor { return NewCachingExecutor(inner, time.Minute) },
+		"recording": func(inner Executor) Executor { return NewRecordingExecutor(inner) },
+	}
+	for name, decorate := range decorators {
+		// the command line must be interpreted by the inner executor
+		out, code, err := decorate(shell).RunLine(`echo "foo bar" | tr a-z A-Z`)
+		assert.NoError(t, err, name)
+		assert.Equal(t, 0, code, name)
+		assert.Equal(t, "FOO BAR\n", out, name)
+
+		out, code, err = decorate(local).RunLine(`FOO="foo bar" sh -c 'echo $FOO'`)
+		assert.NoError(t, err, name)
+		assert.Equal(t, 0, code, name)
+		assert.Equal(t, "foo bar\n", out, name)
+	}
+}
+
+func TestShellExecutorValidated(t *testing.T) {
+	// validated command lines are parsed, so the shell never sees the pipe
+	e := NewValidatingExecutor(NewShellExecutor(), AllowCommands("echo"))
+	out, code, err := e.RunLine(`echo foo | tr a-z A-Z`)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "foo | tr a-z A-Z\n", out)
+}
+
+/* ############################################# */
+/* ###               EscapeArg               ### */
+/* ############################################# */
+
+func TestEscapeArg(t *testing.T) {
+	assert.Equal(t, "''", EscapeArg(""))
+	assert.Equal(t, "'foo'", EscapeArg("foo"))
+	assert.Equal(t, `'it'\''s'`, EscapeArg("it's"))
+	assert.Equal(t, "'a\xffb'", EscapeArg("a\xffb"))
+}
+
+func TestEscapeArgShell(t *testing.T) {
+	e := NewShellExecutor()
+	for _, str := range []string{"", "foo bar", "$HOME", "`id`", "it's", `"\"`, "a;b|c&d", "line1\nline2", "*"} {
+		out, code, err := e.RunLine("printf '%s' " + EscapeArg(str))
+		assert.NoError(t, err)
+		assert.Equal(t, 0, code)
+		assert.Equal(t, str, out)
+	}
+}
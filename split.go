@@ -0,0 +1,47 @@
+package exec
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+
+	"github.com/sbreitf1/errors"
+)
+
+// RunSplit executes a command line with separated arguments and returns stdout and stderr separately. Use ExecuteSplit to check whether a stream has been truncated due to WithMaxStdout or WithMaxStderr.
+func (e *LocalExecutor) RunSplit(command string, args ...string) (string, string, int, errors.Error) {
+	result := e.ExecuteSplit(context.Background(), command, args...)
+	return result.Stdout, result.Stderr, result.ExitCode, result.Err
+}
+
+// ExecuteSplit executes a command line with separated arguments like Execute, but additionally captures stdout and stderr separately in Stdout and Stderr of the result. Both streams are limited independently by WithMaxStdout and WithMaxStderr, while Output contains the combined output subject to WithMaxOutput and WithTailBuffer. Output filters are applied to each stream separately.
+func (e *LocalExecutor) ExecuteSplit(ctx context.Context, command string, args ...string) RunResult {
+	output := e.newOutputBuffer(nil)
+	stdout := &outputBuffer{max: e.maxStdout}
+	stderr := &outputBuffer{max: e.maxStderr}
+	result := e.executeWith(ctx, nil, output, io.MultiWriter(output, stdout), io.MultiWriter(output, stderr), command, args...)
+	result.Stdout = e.filterOutput(e.decodeOutput(stdout.String()))
+	result.Stderr = e.filterOutput(e.decodeOutput(stderr.String()))
+	result.StdoutTruncated = stdout.Truncated()
+	result.StderrTruncated = stderr.Truncated()
+	return result
+}
+
+// RunStdout executes a command line with separated arguments and returns only stdout. Stderr is discarded or forwarded to the writer set by WithStderr, and is neither part of the output nor subject to output limits and filters.
+func (e *LocalExecutor) RunStdout(command string, args ...string) (string, int, errors.Error) {
+	stderr := e.stderr
+	if stderr == nil {
+		stderr = ioutil.Discard
+	}
+	output := e.newOutputBuffer(nil)
+	result := e.executeWith(context.Background(), nil, output, output, stderr, command, args...)
+	return result.Output, result.ExitCode, result.Err
+}
+
+// RunStdout executes a command with given arguments using the DefaultExecutor and returns only stdout. Executors other than LocalExecutor cannot separate the streams and return their regular output.
+func RunStdout(command string, args ...string) (string, int, errors.Error) {
+	if e, ok := DefaultExecutor.(*LocalExecutor); ok {
+		return e.RunStdout(command, args...)
+	}
+	return DefaultExecutor.Run(command, args...)
+}
@@ -0,0 +1,110 @@
+package exec
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/sbreitf1/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+/* ############################################# */
+/* ###               RunSplit                ### */
+/* ############################################# */
+
+func TestRunSplit(t *testing.T) {
+	stdout, stderr, code, err := NewLocalExecutor().RunSplit(path("ordered.sh"))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, code)
+	assert.Equal(t, "out 1\nout 2\n", stdout)
+	assert.Equal(t, "err 1\nerr 2", stderr)
+}
+
+func TestExecuteSplit(t *testing.T) {
+	result := NewLocalExecutor().ExecuteSplit(context.Background(), path("ordered.sh"))
+	assert.NoError(t, result.Err)
+	assert.Equal(t, "out 1\nerr 1\nout 2\nerr 2", result.Output)
+	assert.Equal(t, "out 1\nout 2\n", result.Stdout)
+	assert.Equal(t, "err 1\nerr 2", result.Stderr)
+	assert.False(t, result.StdoutTruncated)
+	assert.False(t, result.StderrTruncated)
+}
+
+func TestExecuteSplitLimits(t *testing.T) {
+	e := NewLocalExecutor(WithMaxStdout(3))
+	result := e.ExecuteSplit(context.Background(), path("ordered.sh"))
+	assert.NoError(t, result.Err)
+	assert.Equal(t, "out", result.Stdout)
+	assert.True(t, result.StdoutTruncated)
+	assert.Equal(t, "err 1\nerr 2", result.Stderr)
+	assert.False(t, result.StderrTruncated)
+	// the combined output is not limited
+	assert.Equal(t, "out 1\nerr 1\nout 2\nerr 2", result.Output)
+	assert.False(t, result.Truncated)
+
+	e = NewLocalExecutor(WithMaxStderr(5), WithMaxOutput(8))
+	result = e.ExecuteSplit(context.Background(), path("ordered.sh"))
+	assert.Equal(t, "out 1\nout 2\n", result.Stdout)
+	assert.False(t, result.StdoutTruncated)
+	assert.Equal(t, "err 1", result.Stderr)
+	assert.True(t, result.StderrTruncated)
+	assert.Equal(t, "out 1\ner", result.Output)
+	assert.True(t, result.Truncated)
+}
+
+func TestExecuteSplitFilters(t *testing.T) {
+	e := NewLocalExecutor(WithOutputFilter(strings.ToUpper))
+	result := e.ExecuteSplit(context.Background(), path("ordered.sh"))
+	assert.Equal(t, "OUT 1\nOUT 2\n", result.Stdout)
+	assert.Equal(t, "ERR 1\nERR 2", result.Stderr)
+}
+
+func TestExecuteSplitJSON(t *testing.T) {
+	result := NewLocalExecutor(WithMaxStdout(3)).ExecuteSplit(context.Background(), "echo", "foo")
+	result.Duration = 0
+	data, err := json.Marshal(result)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"output":"foo\n","exit_code":0,"duration_ms":0,"output_bytes":4,"truncated":false,"escalated_to_kill":false,"stdout":"foo","stdout_truncated":true}`, string(data))
+}
+
+/* ############################################# */
+/* ###               RunStdout               ### */
+/* ############################################# */
+
+func TestRunStdout(t *testing.T) {
+	out, code, err := RunStdout(path("ordered.sh"))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, code)
+	assert.Equal(t, "out 1\nout 2\n", out)
+}
+
+func TestLocalExecutorRunStdoutWithStderr(t *testing.T) {
+	var stderr bytes.Buffer
+	e := NewLocalExecutor(WithStderr(&stderr), WithMaxOutput(5))
+	out, _, err := e.RunStdout(path("ordered.sh"))
+	assert.NoError(t, err)
+	assert.Equal(t, "out 1", out)
+	// stderr is forwarded completely without limits
+	assert.Equal(t, "err 1\nerr 2", stderr.String())
+
+	// other methods still capture stderr
+	stderr.Reset()
+	out, _, err = e.Run(path("ordered.sh"))
+	assert.NoError(t, err)
+	assert.Equal(t, "out 1", out)
+	assert.Equal(t, "", stderr.String())
+}
+
+func TestRunStdoutMock(t *testing.T) {
+	defer restoreDefaultExecutor(DefaultExecutor)
+	DefaultExecutor = NewMockExecutor(func(command string, args ...string) (string, int, errors.Error) {
+		return "combined", 0, nil
+	})
+
+	out, _, err := RunStdout("anything")
+	assert.NoError(t, err)
+	assert.Equal(t, "combined", out)
+}
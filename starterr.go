@@ -0,0 +1,75 @@
+package exec
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+
+	"github.com/sbreitf1/errors"
+)
+
+var (
+	// ErrCommandNotFound occurs when a command could not be found in PATH or at the given location.
+	ErrCommandNotFound = errors.New("Command %q not found")
+	// ErrPermissionDenied occurs when a command exists but may not be executed, for example because the file is not executable.
+	ErrPermissionDenied = errors.New("Permission denied to execute command %q")
+	// ErrArgsTooLong occurs when the arguments of a command exceed the system limit. Reduce the number of arguments or pass them using stdin instead.
+	ErrArgsTooLong = errors.New("Argument list too long for command %q, reduce the arguments or pass them using stdin")
+	// ErrBadInterpreter occurs when a script exists but could not be executed because its interpreter is missing or invalid. This is often caused by a missing shebang line or Windows line endings in the shebang line.
+	ErrBadInterpreter = errors.New("Bad interpreter for command %q, check the shebang line for a missing or wrong interpreter and Windows line endings")
+)
+
+// startError returns a specific error for common reasons why command could not be started and falls back to ErrRun.
+func startError(command string, err error) errors.Error {
+	if isNotFound(command, err) {
+		return ErrCommandNotFound.Args(command).Make().Cause(err)
+	}
+	if os.IsPermission(err) {
+		return ErrPermissionDenied.Args(command).Make().Cause(err)
+	}
+	if isArgsTooLong(err) {
+		return ErrArgsTooLong.Args(command).Make().Cause(err)
+	}
+	if isBadInterpreter(command, err) {
+		return ErrBadInterpreter.Args(command).Make().Cause(err)
+	}
+	return ErrRun.Make().Cause(err)
+}
+
+// isNotFound returns true if err has been caused by a missing executable.
+func isNotFound(command string, err error) bool {
+	if e, ok := err.(*exec.Error); ok && e.Err == exec.ErrNotFound {
+		return true
+	}
+	if os.IsNotExist(err) {
+		// a missing interpreter results in the same error, so make sure the command itself is missing
+		if _, statErr := os.Stat(command); os.IsNotExist(statErr) {
+			return true
+		}
+	}
+	return false
+}
+
+// isArgsTooLong returns true if err has been caused by exceeding the argument size limit.
+func isArgsTooLong(err error) bool {
+	if e, ok := err.(*os.PathError); ok {
+		err = e.Err
+	}
+	return err == syscall.E2BIG
+}
+
+// isBadInterpreter returns true if err has been caused by an unknown executable format or a missing interpreter of an existing script.
+func isBadInterpreter(command string, err error) bool {
+	if e, ok := err.(*os.PathError); ok {
+		err = e.Err
+	}
+	if err == syscall.ENOEXEC {
+		return true
+	}
+	if os.IsNotExist(err) {
+		// isNotFound has already been checked, so the script itself exists
+		_, statErr := os.Stat(command)
+		return statErr == nil
+	}
+	return false
+}
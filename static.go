@@ -0,0 +1,77 @@
+package exec
+
+import (
+	"encoding/json"
+	"io/ioutil"
+
+	"github.com/sbreitf1/errors"
+)
+
+var (
+	// ErrNoStaticResult occurs when a StaticExecutor has no result for a command line.
+	ErrNoStaticResult = errors.New("No static result defined for command line %s")
+	// ErrFixture occurs when a fixture file could not be loaded.
+	ErrFixture = errors.New("Unable to load fixture")
+)
+
+// StaticResult denotes the predetermined outcome of a command.
+type StaticResult struct {
+	Output   string `json:"output"`
+	ExitCode int    `json:"exit_code"`
+}
+
+// StaticExecutor returns predetermined results for command lines without executing anything. Command lines are matched in the normalized form returned by GetCommandLine.
+type StaticExecutor struct {
+	results map[string]StaticResult
+}
+
+// RunLine parses the command line and returns the predetermined result.
+func (e *StaticExecutor) RunLine(commandLine string) (string, int, errors.Error) {
+	return runParsed(e, commandLine)
+}
+
+// Run returns the predetermined result for the command or ErrNoStaticResult if the command is unknown.
+func (e *StaticExecutor) Run(command string, args ...string) (string, int, errors.Error) {
+	commandLine := GetCommandLine(command, args...)
+	result, ok := e.results[commandLine]
+	if !ok {
+		return "", 0, ErrNoStaticResult.Args(commandLine).Make()
+	}
+	return result.Output, result.ExitCode, nil
+}
+
+// RunBytes returns the predetermined result for the command or ErrNoStaticResult if the command is unknown.
+func (e *StaticExecutor) RunBytes(command string, args ...string) ([]byte, int, errors.Error) {
+	output, code, err := e.Run(command, args...)
+	if err != nil {
+		return nil, code, err
+	}
+	return []byte(output), code, nil
+}
+
+// NewStaticExecutor returns an executor with predetermined results for command lines. The keys are normalized using Parse and GetCommandLine, so any equivalent quoting can be used.
+func NewStaticExecutor(results map[string]StaticResult) (*StaticExecutor, errors.Error) {
+	normalized := make(map[string]StaticResult)
+	for commandLine, result := range results {
+		command, args, err := Parse(commandLine)
+		if err != nil {
+			return nil, err
+		}
+		normalized[GetCommandLine(command, args...)] = result
+	}
+	return &StaticExecutor{normalized}, nil
+}
+
+// LoadStaticExecutor returns an executor with predetermined results read from a JSON fixture file that maps command lines to objects with "output" and "exit_code".
+func LoadStaticExecutor(file string) (*StaticExecutor, errors.Error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, ErrFixture.Make().Cause(err)
+	}
+
+	var results map[string]StaticResult
+	if err := json.Unmarshal(data, &results); err != nil {
+		return nil, ErrFixture.Make().Cause(err)
+	}
+	return NewStaticExecutor(results)
+}
@@ -0,0 +1,78 @@
+package exec
+
+import (
+	"testing"
+
+	"github.com/sbreitf1/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+/* ############################################# */
+/* ###            StaticExecutor             ### */
+/* ############################################# */
+
+func TestStaticExecutor(t *testing.T) {
+	e, err := NewStaticExecutor(map[string]StaticResult{
+		"success":          {"some test output here", 0},
+		`fail "with args"`: {"error output", 1},
+	})
+	assert.NoError(t, err)
+
+	out, code, err := e.Run("success")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "some test output here", out)
+
+	out, code, err = e.Run("fail", "with args")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, code)
+	assert.Equal(t, "error output", out)
+
+	out, code, err = e.RunLine(`fail with\ args`)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, code)
+	assert.Equal(t, "error output", out)
+
+	bytes, _, err := e.RunBytes("success")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("some test output here"), bytes)
+}
+
+func TestStaticExecutorUnmatched(t *testing.T) {
+	e, err := NewStaticExecutor(map[string]StaticResult{"success": {"", 0}})
+	assert.NoError(t, err)
+
+	_, _, err = e.Run("fail", "foo bar")
+	assert.True(t, errors.InstanceOf(err, ErrNoStaticResult))
+	assert.Contains(t, err.Error(), `fail foo\ bar`)
+}
+
+func TestStaticExecutorInvalidKey(t *testing.T) {
+	_, err := NewStaticExecutor(map[string]StaticResult{`fail "with args`: {"", 0}})
+	assert.True(t, errors.InstanceOf(err, ErrParse))
+}
+
+func TestLoadStaticExecutor(t *testing.T) {
+	e, err := LoadStaticExecutor(path("static.json"))
+	assert.NoError(t, err)
+
+	out, code, err := e.Run("git", "rev-parse", "--abbrev-ref", "HEAD")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "master\n", out)
+
+	_, code, err = e.Run("git", "config", "--get", "user.name")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, code)
+
+	out, _, err = e.RunLine(`echo 'foo bar'`)
+	assert.NoError(t, err)
+	assert.Equal(t, "foo bar\n", out)
+}
+
+func TestLoadStaticExecutorErrors(t *testing.T) {
+	_, err := LoadStaticExecutor(path("missing.json"))
+	assert.True(t, errors.InstanceOf(err, ErrFixture))
+	_, err = LoadStaticExecutor(path("broken.json"))
+	assert.True(t, errors.InstanceOf(err, ErrFixture))
+}
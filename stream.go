@@ -0,0 +1,37 @@
+package exec
+
+import (
+	"io"
+
+	"github.com/sbreitf1/errors"
+)
+
+// StreamRunner is implemented by executors that are able to forward output while a command is still running.
+type StreamRunner interface {
+	// RunStream executes a command line with separated arguments and writes the output to w while it is produced. The complete output is returned as well.
+	RunStream(w io.Writer, command string, args ...string) (string, int, errors.Error)
+}
+
+// RunStream executes a command with given arguments using the DefaultExecutor and writes the output to w. The output is written after completion if the DefaultExecutor does not implement StreamRunner.
+func RunStream(w io.Writer, command string, args ...string) (string, int, errors.Error) {
+	return runStream(DefaultExecutor, w, command, args...)
+}
+
+func runStream(e Executor, w io.Writer, command string, args ...string) (string, int, errors.Error) {
+	if s, ok := e.(StreamRunner); ok {
+		return s.RunStream(w, command, args...)
+	}
+
+	output, code, err := e.Run(command, args...)
+	io.WriteString(w, output)
+	return output, code, err
+}
+
+// RunQuietOnSuccess executes a command with given arguments using the DefaultExecutor and writes the complete output to w only if the command fails or returns a non-zero exit code. The output of successful commands is discarded.
+func RunQuietOnSuccess(w io.Writer, command string, args ...string) (int, errors.Error) {
+	output, code, err := DefaultExecutor.Run(command, args...)
+	if err != nil || code != 0 {
+		io.WriteString(w, output)
+	}
+	return code, err
+}
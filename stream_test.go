@@ -0,0 +1,99 @@
+package exec
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sbreitf1/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+/* ############################################# */
+/* ###               RunStream               ### */
+/* ############################################# */
+
+func TestRunStream(t *testing.T) {
+	var buf bytes.Buffer
+	out, code, err := RunStream(&buf, path("args.sh"), "foo", "bar")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, code)
+	assert.True(t, strings.Contains(out, "1foo ; 2bar"))
+	assert.Equal(t, out, buf.String())
+}
+
+func TestRunStreamWhileRunning(t *testing.T) {
+	var buf outputBuffer
+	h, err := NewLocalExecutor().start(&buf, path("signal.sh"))
+	assert.NoError(t, err)
+	defer h.Kill()
+
+	for !strings.Contains(buf.String(), "ready") {
+		select {
+		case <-h.Done():
+			assert.FailNow(t, "Process exited without output")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+func TestRunStreamError(t *testing.T) {
+	var buf bytes.Buffer
+	_, _, err := RunStream(&buf, path("noexec.txt"))
+	assert.True(t, errors.InstanceOf(err, ErrPermissionDenied))
+	assert.Equal(t, 0, buf.Len())
+}
+
+func TestMockExecutorRunStreamPartialOutput(t *testing.T) {
+	e := NewMockExecutor(func(command string, args ...string) (string, int, errors.Error) {
+		return "partial output", 0, ErrRun.Make()
+	})
+
+	var buf bytes.Buffer
+	out, _, err := e.RunStream(&buf, "newcommand")
+	assert.Equal(t, "partial output", buf.String())
+	assert.Equal(t, "partial output", out)
+	assert.True(t, errors.InstanceOf(err, ErrRun))
+}
+
+func TestRunStreamFallback(t *testing.T) {
+	e := struct{ Executor }{NewMockExecutor(func(command string, args ...string) (string, int, errors.Error) {
+		return "foobar", 1, nil
+	})}
+
+	var buf bytes.Buffer
+	out, code, err := runStream(e, &buf, "newcommand")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, code)
+	assert.Equal(t, "foobar", out)
+	assert.Equal(t, "foobar", buf.String())
+}
+
+func TestRunQuietOnSuccess(t *testing.T) {
+	var buf bytes.Buffer
+	code, err := RunQuietOnSuccess(&buf, path("success.sh"))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, code)
+	assert.Equal(t, 0, buf.Len())
+}
+
+func TestRunQuietOnSuccessFail(t *testing.T) {
+	var buf bytes.Buffer
+	code, err := RunQuietOnSuccess(&buf, path("fail.sh"))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, code)
+	assert.Equal(t, "error output\n", buf.String())
+}
+
+func TestRunQuietOnSuccessError(t *testing.T) {
+	defer restoreDefaultExecutor(DefaultExecutor)
+	DefaultExecutor = NewMockExecutor(func(command string, args ...string) (string, int, errors.Error) {
+		return "partial output", 0, ErrRun.Make()
+	})
+
+	var buf bytes.Buffer
+	_, err := RunQuietOnSuccess(&buf, "newcommand")
+	assert.True(t, errors.InstanceOf(err, ErrRun))
+	assert.Equal(t, "partial output", buf.String())
+}
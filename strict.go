@@ -0,0 +1,40 @@
+package exec
+
+import (
+	"github.com/sbreitf1/errors"
+)
+
+// StrictExecutor runs commands on an inner Executor and returns ErrReturnCode as *ReturnCodeError for non-zero exit codes like ShouldRun does. Output and exit code are returned unmodified.
+type StrictExecutor struct {
+	inner Executor
+}
+
+// RunLine runs the command line on the inner executor and returns ErrReturnCode for non-zero exit codes.
+func (e *StrictExecutor) RunLine(commandLine string) (string, int, errors.Error) {
+	output, code, err := e.inner.RunLine(commandLine)
+	return output, code, strictError(code, output, err)
+}
+
+// Run executes the command on the inner executor and returns ErrReturnCode for non-zero exit codes.
+func (e *StrictExecutor) Run(command string, args ...string) (string, int, errors.Error) {
+	output, code, err := e.inner.Run(command, args...)
+	return output, code, strictError(code, output, err)
+}
+
+// RunBytes executes the command on the inner executor and returns ErrReturnCode for non-zero exit codes.
+func (e *StrictExecutor) RunBytes(command string, args ...string) ([]byte, int, errors.Error) {
+	output, code, err := e.inner.RunBytes(command, args...)
+	return output, code, strictError(code, string(output), err)
+}
+
+// NewStrictExecutor returns an executor that treats non-zero exit codes of commands run on inner as errors.
+func NewStrictExecutor(inner Executor) *StrictExecutor {
+	return &StrictExecutor{inner}
+}
+
+func strictError(code int, output string, err errors.Error) errors.Error {
+	if err == nil && code != 0 {
+		return newReturnCodeError(code, output)
+	}
+	return err
+}
@@ -0,0 +1,39 @@
+package exec
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sbreitf1/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+/* ############################################# */
+/* ###            StrictExecutor             ### */
+/* ############################################# */
+
+func TestStrictExecutorSuccess(t *testing.T) {
+	e := NewStrictExecutor(NewLocalExecutor())
+	out, code, err := e.RunLine(Quote(path("args.sh")) + " foo bar")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, code)
+	assert.True(t, strings.Contains(out, "1foo ; 2bar"))
+}
+
+func TestStrictExecutorReturnCode(t *testing.T) {
+	e := NewStrictExecutor(NewLocalExecutor())
+	out, code, err := e.Run(path("fail.sh"))
+	assert.True(t, errors.InstanceOf(err, ErrReturnCode))
+	assert.Equal(t, 1, code)
+	assert.True(t, strings.Contains(out, "error output"))
+
+	_, code, err = e.RunBytes(path("fail.sh"))
+	assert.True(t, errors.InstanceOf(err, ErrReturnCode))
+	assert.Equal(t, 1, code)
+}
+
+func TestStrictExecutorRunError(t *testing.T) {
+	e := NewStrictExecutor(NewLocalExecutor())
+	_, _, err := e.Run(path("noexec.txt"))
+	assert.True(t, errors.InstanceOf(err, ErrPermissionDenied))
+}
@@ -0,0 +1,42 @@
+package exec
+
+import (
+	"io"
+
+	"github.com/sbreitf1/errors"
+)
+
+// TeeExecutor copies the output of all commands run on an inner Executor to a writer. Output is mirrored live only if the inner executor implements StreamRunner, like LocalExecutor does. Otherwise it is written as a whole after the command has finished.
+type TeeExecutor struct {
+	inner Executor
+	w     io.Writer
+}
+
+// RunLine runs the command line on the inner executor and copies the output to the writer after the command has finished.
+func (e *TeeExecutor) RunLine(commandLine string) (string, int, errors.Error) {
+	output, code, err := e.inner.RunLine(commandLine)
+	io.WriteString(e.w, output)
+	return output, code, err
+}
+
+// Run executes the command on the inner executor and copies the output to the writer.
+func (e *TeeExecutor) Run(command string, args ...string) (string, int, errors.Error) {
+	return runStream(e.inner, e.w, command, args...)
+}
+
+// RunBytes executes the command on the inner executor and copies the output to the writer after the command has finished.
+func (e *TeeExecutor) RunBytes(command string, args ...string) ([]byte, int, errors.Error) {
+	output, code, err := e.inner.RunBytes(command, args...)
+	e.w.Write(output)
+	return output, code, err
+}
+
+// RunStream executes the command on the inner executor and copies the output to both writers.
+func (e *TeeExecutor) RunStream(w io.Writer, command string, args ...string) (string, int, errors.Error) {
+	return runStream(e.inner, io.MultiWriter(e.w, w), command, args...)
+}
+
+// NewTeeExecutor returns an executor that mirrors the output of all commands run on inner to w.
+func NewTeeExecutor(inner Executor, w io.Writer) *TeeExecutor {
+	return &TeeExecutor{inner, w}
+}
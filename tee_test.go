@@ -0,0 +1,55 @@
+package exec
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/sbreitf1/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+/* ############################################# */
+/* ###              TeeExecutor              ### */
+/* ############################################# */
+
+func TestTeeExecutorRun(t *testing.T) {
+	var buf bytes.Buffer
+	e := NewTeeExecutor(NewLocalExecutor(), &buf)
+	out, code, err := e.Run(path("fail.sh"))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, code)
+	assert.True(t, strings.Contains(out, "error output"))
+	assert.Equal(t, out, buf.String())
+}
+
+func TestTeeExecutorRunLine(t *testing.T) {
+	var buf bytes.Buffer
+	e := NewTeeExecutor(NewLocalExecutor(), &buf)
+	out, code, err := e.RunLine(Quote(path("args.sh")) + ` "foo test space" bar`)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, code)
+	assert.True(t, strings.Contains(out, "1foo test space ; 2bar"))
+	assert.Equal(t, out, buf.String())
+}
+
+func TestTeeExecutorRunStream(t *testing.T) {
+	var buf1, buf2 bytes.Buffer
+	e := NewTeeExecutor(NewLocalExecutor(), &buf1)
+	out, _, err := e.RunStream(&buf2, path("success.sh"))
+	assert.NoError(t, err)
+	assert.Equal(t, out, buf1.String())
+	assert.Equal(t, out, buf2.String())
+}
+
+func TestTeeExecutorNonStreaming(t *testing.T) {
+	var buf bytes.Buffer
+	inner := struct{ Executor }{NewMockExecutor(func(command string, args ...string) (string, int, errors.Error) {
+		return "foobar", 0, nil
+	})}
+	e := NewTeeExecutor(inner, &buf)
+	out, _, err := e.Run("newcommand")
+	assert.NoError(t, err)
+	assert.Equal(t, "foobar", out)
+	assert.Equal(t, "foobar", buf.String())
+}
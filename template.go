@@ -0,0 +1,171 @@
+package exec
+
+import (
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/sbreitf1/errors"
+)
+
+var (
+	// ErrTemplate occurs when a command template could not be loaded or executed.
+	ErrTemplate = errors.New("Unable to process command template")
+)
+
+// ParseTemplateFile reads a text/template command line from file, executes it with data and parses the resulting command line. Injected values should be passed through the template function quote to prevent them from being interpreted as multiple arguments. ErrTemplate is returned if quote is used inside quotes, after a backslash or after a dollar sign like for placeholders of ExpandLineTemplate.
+func ParseTemplateFile(file string, data interface{}) (CommandSpec, errors.Error) {
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return CommandSpec{}, ErrTemplate.Make().Cause(err)
+	}
+
+	// quote only emits markers, which are replaced after their position in the command line has been checked
+	var quoted []string
+	tmpl, err := template.New(file).Funcs(template.FuncMap{"quote": func(str string) string {
+		quoted = append(quoted, str)
+		return quoteMarker(len(quoted) - 1)
+	}}).Parse(string(content))
+	if err != nil {
+		return CommandSpec{}, ErrTemplate.Make().Cause(err)
+	}
+
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, data); err != nil {
+		return CommandSpec{}, ErrTemplate.Make().Cause(err)
+	}
+
+	commandLine, terr := replaceQuoteMarkers(sb.String(), quoted)
+	if terr != nil {
+		return CommandSpec{}, terr
+	}
+	command, args, perr := Parse(strings.TrimSpace(commandLine))
+	if perr != nil {
+		return CommandSpec{}, perr
+	}
+	return CommandSpec{command, args}, nil
+}
+
+// quoteMarker returns the marker for the i-th value passed to the template function quote. Markers are enclosed in NUL characters, which are not allowed in command lines.
+func quoteMarker(i int) string {
+	return "\000" + strconv.Itoa(i) + "\000"
+}
+
+// replaceQuoteMarkers replaces all markers returned by quoteMarker in str by the quoted values.
+func replaceQuoteMarkers(str string, values []string) (string, errors.Error) {
+	var sb strings.Builder
+	var ctx templateContext
+	for {
+		start := strings.IndexRune(str, eol)
+		if start < 0 {
+			sb.WriteString(str)
+			return sb.String(), nil
+		}
+		end := strings.IndexRune(str[start+1:], eol)
+		if end < 0 {
+			// no marker, Parse reports the invalid character
+			sb.WriteString(str)
+			return sb.String(), nil
+		}
+		i, err := strconv.Atoi(str[start+1 : start+1+end])
+		if err != nil || i < 0 || i >= len(values) {
+			ctx.scan(str[:start+1])
+			sb.WriteString(str[:start+1])
+			str = str[start+1:]
+			continue
+		}
+
+		if !ctx.scan(str[:start]) {
+			return "", ErrTemplate.Make().Msg("Template function quote must not be used inside quotes, after a backslash or after a dollar sign")
+		}
+		sb.WriteString(str[:start])
+		sb.WriteString(Quote(values[i]))
+		str = str[start+end+2:]
+	}
+}
+
+// ExpandLineTemplate replaces all {{name}} placeholders in tmpl by the quoted value of name in values and returns the resulting command line. Every value is quoted using Quote, so it always results in exactly one argument regardless of spaces, quotes or other special characters it contains. All other text of tmpl is kept literally. ErrTemplate is returned for unterminated placeholders, placeholders without value and placeholders that are enclosed in quotes, follow a backslash or follow a dollar sign, because the quoted value would be interpreted differently in these places.
+func ExpandLineTemplate(tmpl string, values map[string]string) (string, errors.Error) {
+	var sb strings.Builder
+	var ctx templateContext
+	for {
+		start := strings.Index(tmpl, "{{")
+		if start < 0 {
+			sb.WriteString(tmpl)
+			return sb.String(), nil
+		}
+		end := strings.Index(tmpl[start:], "}}")
+		if end < 0 {
+			return "", ErrTemplate.Make().Msg("Unterminated placeholder in command line template")
+		}
+
+		name := strings.TrimSpace(tmpl[start+2 : start+end])
+		if !ctx.scan(tmpl[:start]) {
+			return "", ErrTemplate.Make().Msg("Placeholder %q must not be quoted, escaped or follow a dollar sign", name)
+		}
+		value, ok := values[name]
+		if !ok {
+			return "", ErrTemplate.Make().Msg("Missing value for placeholder %q", name)
+		}
+		sb.WriteString(tmpl[:start])
+		sb.WriteString(Quote(value))
+		tmpl = tmpl[start+end+2:]
+	}
+}
+
+// templateContext tracks the quoting state of the literal text of a command line template to detect placeholders that would not be expanded to a single argument.
+type templateContext struct {
+	state  int
+	escape bool
+	dollar bool
+}
+
+// scan processes literal and returns true if a placeholder may directly follow it.
+func (c *templateContext) scan(literal string) bool {
+	for _, r := range literal {
+		dollar := c.dollar
+		c.dollar = false
+		if c.escape {
+			c.escape = false
+			continue
+		}
+
+		switch c.state {
+		case parseDefault:
+			switch {
+			case r == esc:
+				c.escape = true
+			case r == sqt && dollar:
+				c.state = parseANSICQuote
+			case r == sqt:
+				c.state = parseSingleQuote
+			case r == dqt:
+				c.state = parseDoubleQuote
+			case r == dlr:
+				c.dollar = true
+			}
+		case parseSingleQuote:
+			if r == sqt {
+				c.state = parseDefault
+			}
+		case parseDoubleQuote, parseANSICQuote:
+			if r == esc {
+				c.escape = true
+			} else if (c.state == parseDoubleQuote && r == dqt) || (c.state == parseANSICQuote && r == sqt) {
+				c.state = parseDefault
+			}
+		}
+	}
+	// the expanded value continues the literal text
+	return c.state == parseDefault && !c.escape && !c.dollar
+}
+
+// RunLineTemplate expands the command line template using ExpandLineTemplate and runs the result using RunLine. This allows to safely insert user-provided values into command lines without quoting them manually.
+func RunLineTemplate(tmpl string, values map[string]string) (string, int, errors.Error) {
+	commandLine, err := ExpandLineTemplate(tmpl, values)
+	if err != nil {
+		return "", 0, err
+	}
+	return RunLine(commandLine)
+}
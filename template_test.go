@@ -0,0 +1,152 @@
+package exec
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sbreitf1/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+/* ############################################# */
+/* ###               Templates               ### */
+/* ############################################# */
+
+func TestParseTemplateFile(t *testing.T) {
+	cmd, err := ParseTemplateFile(path("command.tmpl"), map[string]string{
+		"Script": path("args.sh"),
+		"Name":   `foo "bar"; rm -rf / 'x'`,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, path("args.sh"), cmd.Command)
+	assert.Equal(t, []string{"--name", `foo "bar"; rm -rf / 'x'`, "--mode", "fixed"}, cmd.Args)
+
+	out, code, err := Run(cmd.Command, cmd.Args...)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, code)
+	assert.True(t, strings.Contains(out, `2foo "bar"; rm -rf / 'x'`))
+}
+
+func TestParseTemplateFileStruct(t *testing.T) {
+	cmd, err := ParseTemplateFile(path("command.tmpl"), struct{ Script, Name string }{"newcommand", "a b"})
+	assert.NoError(t, err)
+	assert.Equal(t, "newcommand", cmd.Command)
+	assert.Equal(t, []string{"--name", "a b", "--mode", "fixed"}, cmd.Args)
+}
+
+func TestParseTemplateFileMissing(t *testing.T) {
+	_, err := ParseTemplateFile(path("missing.tmpl"), nil)
+	assert.True(t, errors.InstanceOf(err, ErrTemplate))
+}
+
+func TestParseTemplateFileInvalid(t *testing.T) {
+	_, err := ParseTemplateFile(path("broken.tmpl"), nil)
+	assert.True(t, errors.InstanceOf(err, ErrTemplate))
+}
+
+func TestParseTemplateFileMissingField(t *testing.T) {
+	_, err := ParseTemplateFile(path("command.tmpl"), struct{ Script int }{42})
+	assert.True(t, errors.InstanceOf(err, ErrTemplate))
+}
+
+func TestParseTemplateFileQuotedPosition(t *testing.T) {
+	data := map[string]string{"X": `a" b "c`}
+	for _, content := range []string{`echo "{{quote .X}}"`, `echo '{{quote .X}}'`, `echo \{{quote .X}}`, `echo ${{quote .X}}`, `echo "-m {{quote .X}}" x`} {
+		_, err := ParseTemplateFile(writeTemplate(t, content), data)
+		assert.True(t, errors.InstanceOf(err, ErrTemplate), content)
+	}
+
+	cmd, err := ParseTemplateFile(writeTemplate(t, `echo "a"{{quote .X}} {{quote .X}}`), data)
+	assert.NoError(t, err)
+	assert.Equal(t, CommandSpec{"echo", []string{`aa" b "c`, `a" b "c`}}, cmd)
+}
+
+func TestParseTemplateFileHostileValues(t *testing.T) {
+	file := writeTemplate(t, `echo --value={{quote .X}} {{quote .X}}`)
+	for _, value := range []string{`a" b "c`, `x&&rm -rf /`, `a;b`, "\n", `\`, `'`, "\0001\000", ""} {
+		cmd, err := ParseTemplateFile(file, map[string]string{"X": value})
+		assert.NoError(t, err, value)
+		assert.Equal(t, CommandSpec{"echo", []string{"--value=" + value, value}}, cmd)
+	}
+}
+
+func TestExpandLineTemplate(t *testing.T) {
+	commandLine, err := ExpandLineTemplate(`git commit -m {{msg}} --author={{ author }} {{empty}}`, map[string]string{
+		"msg":    `fix "quotes"; rm -rf / $(id)`,
+		"author": "Jane Doe",
+		"empty":  "",
+	})
+	assert.NoError(t, err)
+	cmd, args, err := Parse(commandLine)
+	assert.NoError(t, err)
+	assert.Equal(t, "git", cmd)
+	assert.Equal(t, []string{"commit", "-m", `fix "quotes"; rm -rf / $(id)`, "--author=Jane Doe", ""}, args)
+}
+
+func TestExpandLineTemplateLiteral(t *testing.T) {
+	commandLine, err := ExpandLineTemplate(`echo "a b" 'c' }} x`, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, `echo "a b" 'c' }} x`, commandLine)
+}
+
+func TestExpandLineTemplateFail(t *testing.T) {
+	_, err := ExpandLineTemplate(`echo {{missing}}`, map[string]string{"other": "x"})
+	assert.True(t, errors.InstanceOf(err, ErrTemplate))
+	assert.True(t, strings.Contains(err.Error(), `"missing"`))
+
+	_, err = ExpandLineTemplate(`echo {{name`, map[string]string{"name": "x"})
+	assert.True(t, errors.InstanceOf(err, ErrTemplate))
+}
+
+func TestExpandLineTemplateQuotedPlaceholder(t *testing.T) {
+	values := map[string]string{"x": `a" b "c`}
+	for _, template := range []string{`echo "{{x}}"`, `echo "-m {{x}}"`, `echo '{{x}}'`, `echo $'{{x}}'`, `echo \{{x}}`, `echo ${{x}}`, `echo "a\" {{x}}"`} {
+		_, err := ExpandLineTemplate(template, values)
+		assert.True(t, errors.InstanceOf(err, ErrTemplate), template)
+	}
+
+	// quotes that have been closed before the placeholder are fine
+	commandLine, err := ExpandLineTemplate(`echo "a"{{x}} 'b'\'{{x}} $'\''{{x}}`, values)
+	assert.NoError(t, err)
+	cmd, args, err := Parse(commandLine)
+	assert.NoError(t, err)
+	assert.Equal(t, "echo", cmd)
+	assert.Equal(t, []string{`aa" b "c`, `b'a" b "c`, `'a" b "c`}, args)
+}
+
+func TestExpandLineTemplateHostileValues(t *testing.T) {
+	hostile := []string{`a" b "c`, `x&&rm -rf /`, `a||b`, `a;b`, `a|b`, `out>f`, `2>x`, `<in`, `$(id)`, "`id`", `'`, `"`, `\`, "a\nb", "tab\there", " ", ""}
+	for _, value := range hostile {
+		commandLine, err := ExpandLineTemplate(`echo --value={{x}} {{x}}`, map[string]string{"x": value})
+		assert.NoError(t, err)
+
+		c, err := ParseConditional(commandLine)
+		assert.NoError(t, err, value)
+		assert.Equal(t, &Conditional{Commands: []ConditionalCommand{{Command: "echo", Args: []string{"--value=" + value, value}}}}, c, value)
+		p, err := ParsePipeline(commandLine)
+		assert.NoError(t, err, value)
+		assert.Equal(t, &Pipeline{Commands: []PipelineCommand{{Command: "echo", Args: []string{"--value=" + value, value}}}}, p, value)
+		commands, err := ParseSequence(commandLine)
+		assert.NoError(t, err, value)
+		assert.Equal(t, [][]string{{"echo", "--value=" + value, value}}, commands, value)
+	}
+}
+
+func TestRunLineTemplate(t *testing.T) {
+	out, code, err := RunLineTemplate(`{{script}} --name {{name}}`, map[string]string{
+		"script": path("args.sh"),
+		"name":   `foo 'bar'; echo injected`,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, code)
+	assert.True(t, strings.Contains(out, `1--name ; 2foo 'bar'; echo injected`))
+}
+
+// writeTemplate writes content to a temporary template file and returns its path.
+func writeTemplate(t *testing.T, content string) string {
+	file := filepath.Join(t.TempDir(), "command.tmpl")
+	assert.NoError(t, ioutil.WriteFile(file, []byte(content), 0644))
+	return file
+}
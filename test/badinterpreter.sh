@@ -0,0 +1,3 @@
+#!/bin/this-interpreter-does-not-exist
+
+echo "unreachable"
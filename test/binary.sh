@@ -0,0 +1,5 @@
+#!/bin/sh
+
+printf '\000\001\377\n'
+
+exit 0
@@ -0,0 +1,7 @@
+#!/bin/sh
+
+sleep 30 &
+
+echo "ready"
+
+wait
@@ -0,0 +1,11 @@
+#!/bin/sh
+
+for i in 1 2 3 4 5; do
+	echo "tick $i"
+	sleep 0.1
+done
+if [ "$1" = "hang" ]; then
+	sleep 30
+fi
+
+exit 0
@@ -0,0 +1,9 @@
+#!/bin/sh
+
+trap '' TERM
+
+echo "ready"
+
+while true; do
+	sleep 0.1
+done
@@ -0,0 +1,9 @@
+#!/bin/sh
+
+i=0
+while [ $i -lt 100 ]; do
+	echo "0123456789"
+	i=$((i+1))
+done
+
+exit 0
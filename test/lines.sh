@@ -0,0 +1,8 @@
+#!/bin/sh
+
+for i in 1 2 3 4 5; do
+	echo "$1 line $i"
+	sleep 0.05
+done
+
+exit 0
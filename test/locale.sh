@@ -0,0 +1,6 @@
+#!/bin/sh
+
+echo "LC_ALL=$LC_ALL LANG=$LANG"
+printf "%s\n" b A a B | sort | tr '\n' ' '
+
+exit 0
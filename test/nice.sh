@@ -0,0 +1,7 @@
+#!/bin/sh
+
+# give the executor time to apply the niceness
+sleep 0.2
+nice
+
+exit 0
@@ -0,0 +1,8 @@
+#!/bin/sh
+
+echo "starting"
+sleep 0.2
+echo "server ready on port 8080"
+sleep 2
+
+exit 0
@@ -0,0 +1,6 @@
+#!/bin/sh
+
+echo "doing some work"
+echo "EXIT:$1"
+
+exit 0
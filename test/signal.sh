@@ -0,0 +1,9 @@
+#!/bin/sh
+
+trap 'echo "caught signal"; exit 3' HUP INT TERM
+
+echo "ready"
+
+while true; do
+	sleep 0.1
+done
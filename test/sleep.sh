@@ -0,0 +1,6 @@
+#!/bin/sh
+
+sleep 0.3
+echo "awake"
+
+exit 0
@@ -0,0 +1,56 @@
+package exec
+
+import (
+	"github.com/sbreitf1/errors"
+)
+
+var (
+	// ErrNotAllowed occurs when a command has been rejected by AllowCommands.
+	ErrNotAllowed = errors.New("Command %q is not allowed")
+)
+
+// ValidatingExecutor checks every command using a validation function before it is run on an inner Executor. Commands are only delegated to the inner executor if the validation succeeds.
+type ValidatingExecutor struct {
+	inner    Executor
+	validate func(command string, args []string) errors.Error
+}
+
+// RunLine parses the command line and runs it using Run. The command line is never passed to RunLine of the inner executor, so the validated command is exactly the one that is run.
+func (e *ValidatingExecutor) RunLine(commandLine string) (string, int, errors.Error) {
+	return runParsed(e, commandLine)
+}
+
+// Run validates the command and executes it on the inner executor. The validation error is returned if the command has been rejected.
+func (e *ValidatingExecutor) Run(command string, args ...string) (string, int, errors.Error) {
+	if err := e.validate(command, args); err != nil {
+		return "", 0, err
+	}
+	return e.inner.Run(command, args...)
+}
+
+// RunBytes validates the command and executes it on the inner executor. The validation error is returned if the command has been rejected.
+func (e *ValidatingExecutor) RunBytes(command string, args ...string) ([]byte, int, errors.Error) {
+	if err := e.validate(command, args); err != nil {
+		return nil, 0, err
+	}
+	return e.inner.RunBytes(command, args...)
+}
+
+// NewValidatingExecutor returns an executor that runs commands on inner only if validate returns no error.
+func NewValidatingExecutor(inner Executor, validate func(command string, args []string) errors.Error) *ValidatingExecutor {
+	return &ValidatingExecutor{inner, validate}
+}
+
+// AllowCommands returns a validation function for NewValidatingExecutor that rejects all commands not contained in commands with ErrNotAllowed. Commands are compared literally, so "ls" and "/bin/ls" are different commands.
+func AllowCommands(commands ...string) func(command string, args []string) errors.Error {
+	allowed := make(map[string]bool)
+	for _, command := range commands {
+		allowed[command] = true
+	}
+	return func(command string, args []string) errors.Error {
+		if !allowed[command] {
+			return ErrNotAllowed.Args(command).Make()
+		}
+		return nil
+	}
+}
@@ -0,0 +1,52 @@
+package exec
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sbreitf1/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+/* ############################################# */
+/* ###          ValidatingExecutor           ### */
+/* ############################################# */
+
+func TestValidatingExecutorAllowed(t *testing.T) {
+	e := NewValidatingExecutor(NewLocalExecutor(), AllowCommands(path("args.sh")))
+	out, code, err := e.RunLine(Quote(path("args.sh")) + ` foo bar`)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, code)
+	assert.True(t, strings.Contains(out, "1foo ; 2bar"))
+}
+
+func TestValidatingExecutorRejected(t *testing.T) {
+	inner := NewDryRunExecutor(0)
+	e := NewValidatingExecutor(inner, AllowCommands("ls"))
+
+	_, _, err := e.Run("rm", "-rf", "/")
+	assert.True(t, errors.InstanceOf(err, ErrNotAllowed))
+	assert.True(t, strings.Contains(err.Error(), `"rm"`))
+	_, _, err = e.RunBytes("/bin/ls")
+	assert.True(t, errors.InstanceOf(err, ErrNotAllowed))
+	_, _, err = e.RunLine("ls -la")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"ls -la"}, inner.Commands())
+}
+
+func TestValidatingExecutorArgs(t *testing.T) {
+	errForbidden := errors.New("Forbidden argument")
+	e := NewValidatingExecutor(NewDryRunExecutor(0), func(command string, args []string) errors.Error {
+		for _, arg := range args {
+			if arg == "--force" {
+				return errForbidden.Make()
+			}
+		}
+		return nil
+	})
+
+	_, _, err := e.Run("git", "push", "--force")
+	assert.True(t, errors.InstanceOf(err, errForbidden))
+	_, _, err = e.Run("git", "push")
+	assert.NoError(t, err)
+}
@@ -0,0 +1,62 @@
+package exec
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/sbreitf1/errors"
+)
+
+// CommandResolver is implemented by executors that are able to check whether a command exists without executing it.
+type CommandResolver interface {
+	// Which returns the absolute path of the executable that would be run for command or ErrCommandNotFound.
+	Which(command string) (string, errors.Error)
+}
+
+// Which resolves command like it would be resolved by Run and returns the absolute path of the executable without executing it. Commands without path separator are looked up in PATH, which is the clean PATH if WithCleanEnv is used. Relative paths are resolved against the working directory set by WithDir. ErrCommandNotFound is returned if no executable file could be found.
+func (e *LocalExecutor) Which(command string) (string, errors.Error) {
+	name := command
+	if e.dir != "" && hasPathSeparator(command) && !filepath.IsAbs(command) {
+		name = filepath.Join(e.dir, command)
+	}
+
+	var err error
+	if e.cleanEnv {
+		name, err = e.lookPath(name)
+	}
+	if err == nil {
+		name, err = exec.LookPath(name)
+	}
+	if err != nil {
+		return "", ErrCommandNotFound.Args(command).Make().Cause(err)
+	}
+
+	path, err := filepath.Abs(name)
+	if err != nil {
+		return "", ErrCommandNotFound.Args(command).Make().Cause(err)
+	}
+	return path, nil
+}
+
+// Which returns the result of WhichCallback or ErrCommandNotFound if no callback is set.
+func (e *MockExecutor) Which(command string) (string, errors.Error) {
+	if e.WhichCallback == nil {
+		return "", ErrCommandNotFound.Args(command).Make()
+	}
+	return e.WhichCallback(command)
+}
+
+// Which resolves command using the DefaultExecutor and returns the absolute path of the executable without executing it. Executors that do not implement CommandResolver fall back to a lookup in the PATH of the current process.
+func Which(command string) (string, errors.Error) {
+	if r, ok := DefaultExecutor.(CommandResolver); ok {
+		return r.Which(command)
+	}
+	return NewLocalExecutor().Which(command)
+}
+
+// hasPathSeparator returns true if command denotes a path instead of a name to look up in PATH.
+func hasPathSeparator(command string) bool {
+	return strings.ContainsRune(command, '/') || strings.ContainsRune(command, os.PathSeparator)
+}
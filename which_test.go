@@ -0,0 +1,76 @@
+package exec
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sbreitf1/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+/* ############################################# */
+/* ###                 Which                 ### */
+/* ############################################# */
+
+func TestWhich(t *testing.T) {
+	resolved, err := Which("sh")
+	assert.NoError(t, err)
+	assert.True(t, filepath.IsAbs(resolved))
+	assert.Equal(t, "sh", filepath.Base(resolved))
+
+	_, err = Which("unknown-command-that-does-not-exist")
+	assert.True(t, errors.InstanceOf(err, ErrCommandNotFound))
+}
+
+func TestWhichPath(t *testing.T) {
+	wd, _ := os.Getwd()
+	resolved, err := Which(path("success.sh"))
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(wd, "test", "success.sh"), resolved)
+
+	_, err = Which(path("missing.sh"))
+	assert.True(t, errors.InstanceOf(err, ErrCommandNotFound))
+	// files without execute permission cannot be run
+	_, err = Which(path("noexec.txt"))
+	assert.True(t, errors.InstanceOf(err, ErrCommandNotFound))
+}
+
+func TestLocalExecutorWhichDir(t *testing.T) {
+	wd, _ := os.Getwd()
+	resolved, err := NewLocalExecutor(WithDir("test")).Which("./success.sh")
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(wd, "test", "success.sh"), resolved)
+}
+
+func TestLocalExecutorWhichCleanEnv(t *testing.T) {
+	wd, _ := os.Getwd()
+	e := NewLocalExecutor(WithCleanEnv(map[string]string{"PATH": filepath.Join(wd, "test")}))
+	resolved, err := e.Which("success.sh")
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(wd, "test", "success.sh"), resolved)
+
+	// the PATH of the current process is not used
+	_, err = e.Which("sh")
+	assert.True(t, errors.InstanceOf(err, ErrCommandNotFound))
+}
+
+func TestWhichMock(t *testing.T) {
+	defer restoreDefaultExecutor(DefaultExecutor)
+	mock := NewMockExecutor(func(command string, args ...string) (string, int, errors.Error) {
+		return "", 0, nil
+	})
+	DefaultExecutor = mock
+
+	_, err := Which("sh")
+	assert.True(t, errors.InstanceOf(err, ErrCommandNotFound))
+
+	mock.WhichCallback = func(command string) (string, errors.Error) {
+		return "/remote/bin/" + command, nil
+	}
+	resolved, err := Which("git")
+	assert.NoError(t, err)
+	assert.Equal(t, "/remote/bin/git", resolved)
+	// resolving commands does not execute them
+	assert.Empty(t, mock.Calls())
+}
@@ -0,0 +1,90 @@
+package exec
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+/* ############################################# */
+/* ###                Reaping                ### */
+/* ############################################# */
+
+func TestNoZombiesAfterErrors(t *testing.T) {
+	Run("unknown-command-that-does-not-exist")
+	Run(path("noexec.txt"))
+	Run(path("fail.sh"))
+	NewLocalExecutor(WithTimeout(50 * time.Millisecond)).Run(path("sleep.sh"))
+	NewLocalExecutor(WithIdleTimeout(50 * time.Millisecond)).Run(path("sleep.sh"))
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	NewLocalExecutor(WithGracefulShutdown(syscall.SIGTERM, 50*time.Millisecond)).RunContext(ctx, path("ignoreterm.sh"))
+
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	RunContext(ctx, path("success.sh"))
+
+	assert.Equal(t, 0, waitForZombies(t))
+}
+
+func TestNoZombiesAfterPipelineErrors(t *testing.T) {
+	e := NewLocalExecutor()
+	// the first stage has already been started when the second one fails
+	e.RunPipeline(CommandSpec{Command: path("sleep.sh")}, CommandSpec{Command: "unknown-command-that-does-not-exist"})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	e.RunPipelineContext(ctx, CommandSpec{Command: path("sleep.sh")}, CommandSpec{Command: "cat"})
+
+	assert.Equal(t, 0, waitForZombies(t))
+}
+
+func TestNoZombiesAfterAbandonedHandle(t *testing.T) {
+	h, err := NewLocalExecutor().Start(path("success.sh"))
+	assert.NoError(t, err)
+	h.Kill()
+
+	// the handle is never waited for
+	assert.Equal(t, 0, waitForZombies(t))
+}
+
+// waitForZombies returns the number of defunct child processes of the test process, which are given a short time to be reaped asynchronously.
+func waitForZombies(t *testing.T) int {
+	var zombies int
+	for i := 0; i < 20; i++ {
+		zombies = countZombies(t)
+		if zombies == 0 {
+			break
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	return zombies
+}
+
+func countZombies(t *testing.T) int {
+	files, err := filepath.Glob("/proc/[0-9]*/stat")
+	assert.NoError(t, err)
+
+	pid := strconv.Itoa(os.Getpid())
+	zombies := 0
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			// the process has exited in the meantime
+			continue
+		}
+		// the command name in parentheses may contain spaces and is skipped
+		fields := strings.Fields(string(data[strings.LastIndexByte(string(data), ')')+1:]))
+		if len(fields) > 1 && fields[0] == "Z" && fields[1] == pid {
+			zombies++
+		}
+	}
+	return zombies
+}